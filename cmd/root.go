@@ -1,16 +1,25 @@
 package cmd
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/marcopollivier/rag-go-ex01/internal/experiment"
 	"github.com/marcopollivier/rag-go-ex01/internal/handlers"
+	"github.com/marcopollivier/rag-go-ex01/internal/knowledge"
+	"github.com/marcopollivier/rag-go-ex01/internal/language"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
 	"github.com/marcopollivier/rag-go-ex01/internal/openai"
+	"github.com/marcopollivier/rag-go-ex01/internal/prompts"
 	"github.com/marcopollivier/rag-go-ex01/internal/qdrant"
 	"github.com/marcopollivier/rag-go-ex01/internal/rag"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
 	"github.com/sirupsen/logrus"
 )
 
@@ -31,17 +40,143 @@ func Execute() {
 	logger.Info("Inicializando cliente OpenAI...")
 	openaiClient := openai.NewClient(openaiAPIKey, logger)
 
+	// ### KNOWLEDGE BASES ###
+	// Bases de conhecimento (opcional): arquivo JSON com nome, descrição e coleção de cada base
+	bases := knowledge.Default()
+	basesFile := os.Getenv("KNOWLEDGE_BASES_FILE")
+	if basesFile != "" {
+		var err error
+		bases, err = knowledge.Load(basesFile)
+		if err != nil {
+			log.Fatalf("Erro ao carregar bases de conhecimento: %v", err)
+		}
+		logger.Infof("Bases de conhecimento carregadas de %s", basesFile)
+	}
+
 	// ### QDRANT CLIENT CONFIG ###
-	logger.Info("Inicializando cliente Qdrant...")
-	qdrantClient, err := qdrant.NewClient("localhost", 6333, "rag_documents", logger)
+	logger.Info("Inicializando clientes Qdrant...")
+	knowledgeBases := make([]rag.KnowledgeBase, 0, len(bases))
+	for _, base := range bases {
+		qdrantClient, err := qdrant.NewClient("localhost", 6333, base.Collection, base.Config, logger)
+		if err != nil {
+			log.Fatalf("Erro ao inicializar cliente Qdrant da base '%s': %v", base.Name, err)
+		}
+		knowledgeBases = append(knowledgeBases, rag.KnowledgeBase{Base: base, Client: qdrantClient})
+	}
+
+	// Roteamento padrão entre bases de conhecimento: embedding, llm ou all
+	routing := os.Getenv("ROUTING_MODE")
+	switch routing {
+	case "", models.RoutingEmbedding, models.RoutingLLM, models.RoutingAll:
+	default:
+		log.Fatalf("ROUTING_MODE inválido: %s", routing)
+	}
+
+	// ### PROMPT TEMPLATES ###
+	// Diretório com um subdiretório por template e um arquivo por idioma (ex.: prompts/default/pt-BR.tmpl)
+	promptDir := os.Getenv("PROMPT_TEMPLATES_DIR")
+	if promptDir == "" {
+		promptDir = "prompts"
+	}
+	promptTemplate := os.Getenv("PROMPT_TEMPLATE")
+	if promptTemplate == "" {
+		promptTemplate = "default"
+	}
+	logger.Infof("Carregando templates de prompt de %s...", promptDir)
+	promptStore, err := prompts.NewStore(promptDir, promptTemplate, logger)
 	if err != nil {
-		log.Fatalf("Erro ao inicializar cliente Qdrant: %v", err)
+		log.Fatalf("Erro ao carregar templates de prompt: %v", err)
+	}
+
+	// Intervalo de verificação de mudanças nos templates (ex.: 5s); 0 desativa o recarregamento
+	promptReloadInterval := 5 * time.Second
+	if interval := os.Getenv("PROMPT_RELOAD_INTERVAL"); interval != "" {
+		promptReloadInterval, err = time.ParseDuration(interval)
+		if err != nil {
+			log.Fatalf("PROMPT_RELOAD_INTERVAL inválido: %v", err)
+		}
+	}
+	if promptReloadInterval > 0 {
+		go promptStore.Watch(context.Background(), promptReloadInterval)
 	}
 
 	// ### Starting SERVICE ###
 	logger.Info("Inicializando serviço RAG...")
-	s := rag.NewService(openaiClient, qdrantClient, logger)
-	handler := handlers.NewHandler(s, logger)
+	// Política de resposta padrão da coleção: grounded_only, grounded_preferred ou open
+	answerPolicy := os.Getenv("ANSWER_POLICY")
+	switch answerPolicy {
+	case "", models.AnswerPolicyGroundedOnly, models.AnswerPolicyGroundedPreferred, models.AnswerPolicyOpen:
+	default:
+		log.Fatalf("ANSWER_POLICY inválida: %s", answerPolicy)
+	}
+
+	// Expiração por inatividade das sessões de conversa (ex.: 30m, 2h)
+	var chatSessionTTL time.Duration
+	if ttl := os.Getenv("CHAT_SESSION_TTL"); ttl != "" {
+		chatSessionTTL, err = time.ParseDuration(ttl)
+		if err != nil {
+			log.Fatalf("CHAT_SESSION_TTL inválido: %v", err)
+		}
+	}
+
+	// Idioma padrão das respostas e idioma dos documentos indexados: pt-BR, en ou es
+	defaultLanguage := os.Getenv("DEFAULT_LANGUAGE")
+	if defaultLanguage != "" && !language.IsSupported(defaultLanguage) {
+		log.Fatalf("DEFAULT_LANGUAGE inválido: %s", defaultLanguage)
+	}
+	documentsLanguage := os.Getenv("DOCUMENTS_LANGUAGE")
+	if documentsLanguage != "" && !language.IsSupported(documentsLanguage) {
+		log.Fatalf("DOCUMENTS_LANGUAGE inválido: %s", documentsLanguage)
+	}
+	// Tradução das perguntas em outro idioma para o idioma dos documentos (uma chamada ao modelo por consulta)
+	translateQueries := os.Getenv("TRANSLATE_QUERIES") == "true"
+
+	// Experimentos A/B (opcional): arquivo JSON com variantes e pesos de tráfego
+	var experiments *experiment.Manager
+	if experimentsFile := os.Getenv("EXPERIMENTS_FILE"); experimentsFile != "" {
+		experiments, err = experiment.Load(experimentsFile)
+		if err != nil {
+			log.Fatalf("Erro ao carregar experimentos: %v", err)
+		}
+		logger.Infof("Experimentos carregados de %s", experimentsFile)
+	}
+
+	// Tenants (opcional): arquivo JSON com chaves de API, isolamento e cotas diárias de cada tenant
+	var tenants *tenant.Registry
+	if tenantsFile := os.Getenv("TENANTS_FILE"); tenantsFile != "" {
+		tenants, err = tenant.Load(tenantsFile)
+		if err != nil {
+			log.Fatalf("Erro ao carregar tenants: %v", err)
+		}
+		logger.Infof("Tenants carregados de %s", tenantsFile)
+	}
+	// Aceita o cabeçalho X-Tenant-ID sem chave de API (apenas atrás de um gateway que autentica o cliente)
+	trustTenantHeader := os.Getenv("TENANT_TRUST_HEADER") == "true"
+	// Chave exigida pelas rotas de administração de coleções
+	adminAPIKey := os.Getenv("ADMIN_API_KEY")
+	// Origens aceitas no WebSocket de chat, separadas por vírgula; sem elas, só a mesma origem
+	var wsAllowedOrigins []string
+	for _, origin := range strings.Split(os.Getenv("WS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			wsAllowedOrigins = append(wsAllowedOrigins, origin)
+		}
+	}
+
+	s, err := rag.NewService(openaiClient, knowledgeBases, promptStore, rag.Config{
+		AnswerPolicy:       answerPolicy,
+		ChatSessionTTL:     chatSessionTTL,
+		DefaultLanguage:    defaultLanguage,
+		DocumentsLanguage:  documentsLanguage,
+		TranslateQueries:   translateQueries,
+		Experiments:        experiments,
+		KnowledgeBasesFile: basesFile,
+		Routing:            routing,
+		Tenants:            tenants,
+	}, logger)
+	if err != nil {
+		log.Fatalf("Erro ao inicializar serviço RAG: %v", err)
+	}
+	handler := handlers.NewHandler(s, wsAllowedOrigins, logger)
 
 	router := gin.Default()
 	api := router.Group("/api/v1")
@@ -54,19 +189,73 @@ func Execute() {
 			})
 		})
 
+		// Administração de coleções: protegida por ADMIN_API_KEY; sem chave, só fica aberta quando
+		// não há tenants configurados
+		switch {
+		case adminAPIKey != "":
+			admin := api.Group("/admin", handlers.AdminMiddleware(adminAPIKey, logger))
+			registerAdminRoutes(admin, handler)
+		case tenants == nil:
+			registerAdminRoutes(api.Group("/admin"), handler)
+		default:
+			logger.Warn("ADMIN_API_KEY não configurada: rotas de administração de coleções desativadas")
+		}
+
+		// Com tenants configurados, as rotas registradas a seguir exigem um tenant válido
+		if tenants != nil {
+			api.Use(handlers.TenantMiddleware(tenants, trustTenantHeader, logger))
+		}
+		api.GET("/tenant", handler.TenantUsage) // Tenant da requisição, cotas e consumo do dia
+
 		api.POST("/index", handler.IndexDocuments)         // Indexar documentos
 		api.POST("/index/sample", handler.IndexSampleData) // Indexar dados de exemplo
 
-		api.POST("/query", handler.Query)     // Query principal
-		api.GET("/query", handler.QuickQuery) // Query via GET para testes
+		api.POST("/query", handler.Query)              // Query principal
+		api.POST("/query/stream", handler.QueryStream) // Query com resposta em streaming (SSE)
+		api.GET("/query", handler.QuickQuery)          // Query via GET para testes
+		api.POST("/search", handler.Search)            // Busca sem geração de resposta
+		api.POST("/agent", handler.Agent)              // Resposta com ferramentas em vários passos
+
+		// Conversa com memória de sessão
+		api.POST("/chat", handler.Chat)                             // Mensagem em uma conversa
+		api.POST("/chat/sessions", handler.CreateChatSession)       // Criar sessão
+		api.GET("/chat/sessions", handler.ListChatSessions)         // Listar sessões ativas
+		api.GET("/chat/sessions/:id", handler.GetChatSession)       // Histórico da sessão
+		api.DELETE("/chat/sessions/:id", handler.DeleteChatSession) // Remover sessão
+		api.GET("/chat/ws", handler.ChatWebSocket)                  // Chat interativo via WebSocket
 
 		// Novas rotas para explorar documentos
 		api.GET("/documents", handler.GetAllDocuments)                     // Listar todos os documentos
 		api.GET("/documents/source/:source", handler.GetDocumentsBySource) // Documentos por fonte
+		api.GET("/documents/export", handler.ExportDocuments)              // Exportação completa em NDJSON
+		api.POST("/documents/delete", handler.DeleteByFilter)              // Remover documentos por filtro
+		api.POST("/documents/update", handler.UpdateByFilter)              // Alterar metadados por filtro
+		api.GET("/documents/:id", handler.GetDocument)                     // Documento por ID
+		api.PATCH("/documents/:id", handler.UpdateDocument)                // Alterar metadados ou conteúdo
+		api.DELETE("/documents/:id", handler.DeleteDocument)               // Remover documento e trechos
+		api.GET("/documents/:id/similar", handler.GetSimilarDocuments)     // Documentos relacionados
 		api.GET("/collection/info", handler.GetCollectionInfo)             // Informações da coleção
+		api.GET("/collections", handler.ListKnowledgeBases)                // Bases de conhecimento
+		api.GET("/collections/:name", handler.DescribeKnowledgeBase)       // Configuração e estado da coleção
+		api.GET("/collections/:name/export", handler.ExportCollection)     // Exportação da coleção em JSONL
+		api.POST("/collections/:name/import", handler.ImportCollection)    // Importação de JSONL, sem re-embedding
+
+		api.GET("/prompts", handler.ListPromptTemplates) // Templates de prompt carregados
+
+		// Experimentos A/B e feedback dos usuários
+		api.POST("/feedback", handler.Feedback)            // Avaliar uma resposta
+		api.GET("/experiments", handler.ExperimentResults) // Métricas por variante
 	}
 
 	if err := router.Run(":8080"); err != nil {
 		log.Fatalf("Erro ao iniciar servidor: %v", err)
 	}
 }
+
+// registerAdminRoutes registra as rotas de criação, alteração e remoção de coleções
+func registerAdminRoutes(admin *gin.RouterGroup, handler *handlers.Handler) {
+	admin.POST("/collections", handler.CreateKnowledgeBase)                // Criar base e coleção
+	admin.PATCH("/collections/:name", handler.UpdateKnowledgeBase)         // Alterar descrição e parâmetros
+	admin.DELETE("/collections/:name", handler.DropKnowledgeBase)          // Remover base e coleção
+	admin.POST("/collections/:name/import", handler.AdminImportCollection) // Importação, criando a base se faltar
+}
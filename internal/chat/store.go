@@ -0,0 +1,160 @@
+package chat
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+// ErrSessionNotFound indica que a sessão não existe ou já expirou
+var ErrSessionNotFound = errors.New("sessão não encontrada")
+
+// Store mantém as sessões de conversa em memória, com expiração por inatividade. Cada sessão pertence
+// a um dono (o tenant, ou vazio sem tenants) e só é visível para ele.
+type Store struct {
+	mu         sync.Mutex
+	sessions   map[string]*models.ChatSession
+	owners     map[string]string
+	defaultTTL time.Duration
+}
+
+// NewStore cria um repositório de sessões com o TTL padrão informado
+func NewStore(defaultTTL time.Duration) *Store {
+	return &Store{
+		sessions:   make(map[string]*models.ChatSession),
+		owners:     make(map[string]string),
+		defaultTTL: defaultTTL,
+	}
+}
+
+// Create cria uma sessão vazia do dono informado; ttl zero usa o TTL padrão
+func (s *Store) Create(owner string, ttl time.Duration) models.ChatSession {
+	if ttl <= 0 {
+		ttl = s.defaultTTL
+	}
+
+	now := time.Now()
+	session := &models.ChatSession{
+		ID:         uuid.New().String(),
+		Messages:   []models.ChatMessage{},
+		TTLSeconds: int(ttl.Seconds()),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeExpired(now)
+	s.sessions[session.ID] = session
+	s.owners[session.ID] = owner
+
+	return copySession(session)
+}
+
+// Get retorna uma cópia da sessão
+func (s *Store) Get(owner, id string) (models.ChatSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.get(owner, id, time.Now())
+	if err != nil {
+		return models.ChatSession{}, err
+	}
+	return copySession(session), nil
+}
+
+// Append adiciona mensagens à sessão e renova sua expiração
+func (s *Store) Append(owner, id string, messages ...models.ChatMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	session, err := s.get(owner, id, now)
+	if err != nil {
+		return err
+	}
+
+	session.Messages = append(session.Messages, messages...)
+	session.UpdatedAt = now
+	session.ExpiresAt = now.Add(time.Duration(session.TTLSeconds) * time.Second)
+	return nil
+}
+
+// List retorna o resumo das sessões ativas do dono, das mais recentes para as mais antigas
+func (s *Store) List(owner string) []models.ChatSessionSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purgeExpired(time.Now())
+
+	summaries := make([]models.ChatSessionSummary, 0, len(s.sessions))
+	for id, session := range s.sessions {
+		if s.owners[id] != owner {
+			continue
+		}
+		summaries = append(summaries, models.ChatSessionSummary{
+			ID:           session.ID,
+			MessageCount: len(session.Messages),
+			CreatedAt:    session.CreatedAt,
+			UpdatedAt:    session.UpdatedAt,
+			ExpiresAt:    session.ExpiresAt,
+		})
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt)
+	})
+	return summaries
+}
+
+// Delete remove a sessão
+func (s *Store) Delete(owner, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.get(owner, id, time.Now()); err != nil {
+		return err
+	}
+	s.remove(id)
+	return nil
+}
+
+// get busca uma sessão ativa do dono, descartando-a se já expirou. Sessões de outro dono são
+// tratadas como inexistentes. Deve ser chamado com o lock adquirido.
+func (s *Store) get(owner, id string, now time.Time) (*models.ChatSession, error) {
+	session, ok := s.sessions[id]
+	if !ok || s.owners[id] != owner {
+		return nil, ErrSessionNotFound
+	}
+	if now.After(session.ExpiresAt) {
+		s.remove(id)
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// remove descarta a sessão. Deve ser chamado com o lock adquirido.
+func (s *Store) remove(id string) {
+	delete(s.sessions, id)
+	delete(s.owners, id)
+}
+
+// purgeExpired remove as sessões expiradas. Deve ser chamado com o lock adquirido.
+func (s *Store) purgeExpired(now time.Time) {
+	for id, session := range s.sessions {
+		if now.After(session.ExpiresAt) {
+			s.remove(id)
+		}
+	}
+}
+
+func copySession(session *models.ChatSession) models.ChatSession {
+	copied := *session
+	copied.Messages = append([]models.ChatMessage{}, session.Messages...)
+	return copied
+}
@@ -0,0 +1,368 @@
+package experiment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// exposureTTL é por quanto tempo uma consulta pode receber feedback atribuído à sua variante
+const exposureTTL = 24 * time.Hour
+
+// Variant é uma variante de experimento. Campos vazios mantêm a configuração padrão do serviço.
+type Variant struct {
+	ID             string   `json:"id"`
+	Weight         int      `json:"weight"`
+	PromptTemplate string   `json:"prompt_template,omitempty"`
+	Model          string   `json:"model,omitempty"`
+	Temperature    *float32 `json:"temperature,omitempty"`
+	TopK           int      `json:"top_k,omitempty"`
+	Threshold      float32  `json:"threshold,omitempty"`
+}
+
+// Experiment define as variantes em teste e seus pesos de tráfego
+type Experiment struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description,omitempty"`
+	Enabled     bool      `json:"enabled"`
+	Variants    []Variant `json:"variants"`
+}
+
+// Assignment é a variante atribuída a uma consulta
+type Assignment struct {
+	ExperimentID string
+	Variant      Variant
+}
+
+// Usage é o consumo de tokens registrado em uma exposição
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// VariantResult agrega as métricas de uma variante
+type VariantResult struct {
+	VariantID        string  `json:"variant_id"`
+	Weight           int     `json:"weight"`
+	Requests         int     `json:"requests"`
+	AvgLatencyMs     float64 `json:"avg_latency_ms"`
+	AvgTotalTokens   float64 `json:"avg_total_tokens"`
+	Feedback         int     `json:"feedback"`
+	PositiveFeedback int     `json:"positive_feedback"`
+	// PositiveRate é a fração de feedback positivo (0 quando ainda não há feedback)
+	PositiveRate float64 `json:"positive_rate"`
+}
+
+// Result agrega as métricas de um experimento
+type Result struct {
+	ExperimentID string          `json:"experiment_id"`
+	Description  string          `json:"description,omitempty"`
+	Enabled      bool            `json:"enabled"`
+	Variants     []VariantResult `json:"variants"`
+}
+
+// variantStats acumula as métricas de uma variante
+type variantStats struct {
+	requests         int
+	totalLatencyMs   int64
+	totalTokens      int
+	feedback         int
+	positiveFeedback int
+}
+
+// ErrFeedbackRecorded indica que a consulta já recebeu feedback
+var ErrFeedbackRecorded = errors.New("feedback já registrado para esta consulta")
+
+// statsKey identifica as métricas de uma variante em um escopo (tenant)
+type statsKey struct {
+	scope        string
+	experimentID string
+	variantID    string
+}
+
+// exposure registra a variante servida em uma consulta, para atribuir o feedback posterior
+type exposure struct {
+	scope      string
+	assignment Assignment
+	at         time.Time
+	rated      bool
+}
+
+// Manager atribui variantes aos usuários e acumula as métricas dos experimentos em memória
+type Manager struct {
+	experiments []Experiment
+	active      *Experiment
+
+	mu        sync.Mutex
+	stats     map[statsKey]*variantStats
+	exposures map[string]*exposure
+	lastPurge time.Time
+}
+
+// Load lê os experimentos de um arquivo JSON no formato {"experiments": [...]}. No máximo um
+// experimento pode estar habilitado, para que as variantes não disputem os mesmos parâmetros.
+func Load(path string) (*Manager, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo de experimentos: %w", err)
+	}
+
+	var file struct {
+		Experiments []Experiment `json:"experiments"`
+	}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar arquivo de experimentos: %w", err)
+	}
+
+	return NewManager(file.Experiments)
+}
+
+// NewManager valida os experimentos e cria o gerenciador
+func NewManager(experiments []Experiment) (*Manager, error) {
+	m := &Manager{
+		experiments: experiments,
+		stats:       make(map[statsKey]*variantStats),
+		exposures:   make(map[string]*exposure),
+	}
+
+	seen := make(map[string]bool)
+	for i := range experiments {
+		exp := &experiments[i]
+		if err := validate(exp); err != nil {
+			return nil, err
+		}
+		if seen[exp.ID] {
+			return nil, fmt.Errorf("experimento '%s' duplicado", exp.ID)
+		}
+		seen[exp.ID] = true
+
+		if exp.Enabled {
+			if m.active != nil {
+				return nil, fmt.Errorf("apenas um experimento pode estar habilitado ('%s' e '%s')", m.active.ID, exp.ID)
+			}
+			m.active = exp
+		}
+	}
+
+	return m, nil
+}
+
+func validate(exp *Experiment) error {
+	if exp.ID == "" {
+		return errors.New("experimento sem id")
+	}
+	if len(exp.Variants) == 0 {
+		return fmt.Errorf("experimento '%s' sem variantes", exp.ID)
+	}
+
+	seen := make(map[string]bool)
+	totalWeight := 0
+	for _, variant := range exp.Variants {
+		if variant.ID == "" {
+			return fmt.Errorf("experimento '%s' tem variante sem id", exp.ID)
+		}
+		if seen[variant.ID] {
+			return fmt.Errorf("experimento '%s' tem a variante '%s' duplicada", exp.ID, variant.ID)
+		}
+		seen[variant.ID] = true
+
+		if variant.Weight < 0 {
+			return fmt.Errorf("variante '%s' do experimento '%s' tem peso negativo", variant.ID, exp.ID)
+		}
+		if err := validateVariantParams(variant); err != nil {
+			return fmt.Errorf("variante '%s' do experimento '%s': %w", variant.ID, exp.ID, err)
+		}
+		totalWeight += variant.Weight
+	}
+	if totalWeight == 0 {
+		return fmt.Errorf("experimento '%s' sem tráfego (soma dos pesos é zero)", exp.ID)
+	}
+	return nil
+}
+
+// validateVariantParams aplica aos parâmetros da variante os mesmos limites aceitos na consulta
+// (top_k de 1 a 100 e threshold de 0 a 1) e os limites de temperatura da API de chat (0 a 2)
+func validateVariantParams(variant Variant) error {
+	if variant.TopK < 0 || variant.TopK > 100 {
+		return fmt.Errorf("top_k %d fora do intervalo de 1 a 100", variant.TopK)
+	}
+	if variant.Threshold < 0 || variant.Threshold > 1 {
+		return fmt.Errorf("threshold %.2f fora do intervalo de 0 a 1", variant.Threshold)
+	}
+	if variant.Temperature != nil && (*variant.Temperature < 0 || *variant.Temperature > 2) {
+		return fmt.Errorf("temperature %.2f fora do intervalo de 0 a 2", *variant.Temperature)
+	}
+	return nil
+}
+
+// CheckPromptTemplates verifica, com a função exists, se os templates de prompt das variantes existem
+func (m *Manager) CheckPromptTemplates(exists func(name string) bool) error {
+	if m == nil {
+		return nil
+	}
+	for _, exp := range m.experiments {
+		for _, variant := range exp.Variants {
+			if variant.PromptTemplate != "" && !exists(variant.PromptTemplate) {
+				return fmt.Errorf("variante '%s' do experimento '%s' usa o template de prompt inexistente '%s'",
+					variant.ID, exp.ID, variant.PromptTemplate)
+			}
+		}
+	}
+	return nil
+}
+
+// Assign atribui uma variante do experimento habilitado à chave do usuário. A atribuição é
+// determinística: a mesma chave recebe sempre a mesma variante enquanto os pesos não mudarem.
+// Retorna nil quando não há experimento habilitado.
+func (m *Manager) Assign(userKey string) *Assignment {
+	if m == nil || m.active == nil {
+		return nil
+	}
+
+	totalWeight := 0
+	for _, variant := range m.active.Variants {
+		totalWeight += variant.Weight
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(m.active.ID + ":" + userKey))
+	bucket := int(hash.Sum64() % uint64(totalWeight))
+
+	for _, variant := range m.active.Variants {
+		if bucket < variant.Weight {
+			return &Assignment{ExperimentID: m.active.ID, Variant: variant}
+		}
+		bucket -= variant.Weight
+	}
+	return nil
+}
+
+// RecordExposure registra a latência e o consumo de uma consulta servida pela variante. As métricas
+// são separadas por escopo (o tenant da consulta; vazio sem tenants).
+func (m *Manager) RecordExposure(scope string, queryID string, assignment *Assignment, latency time.Duration, usage Usage) {
+	if m == nil || assignment == nil {
+		return
+	}
+
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.purgeExposures(now)
+	m.exposures[queryID] = &exposure{scope: scope, assignment: *assignment, at: now}
+
+	stats := m.variantStats(scope, assignment)
+	stats.requests++
+	stats.totalLatencyMs += latency.Milliseconds()
+	stats.totalTokens += usage.TotalTokens
+}
+
+// RecordFeedback atribui o feedback do usuário à variante que serviu a consulta no mesmo escopo.
+// Retorna a atribuição encontrada, ou nil se a consulta não fez parte de um experimento (ou expirou).
+// Cada consulta conta um único feedback; os seguintes retornam ErrFeedbackRecorded.
+func (m *Manager) RecordFeedback(scope string, queryID string, positive bool) (*Assignment, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	exp, ok := m.exposures[queryID]
+	if !ok || exp.scope != scope || time.Since(exp.at) > exposureTTL {
+		return nil, nil
+	}
+	if exp.rated {
+		return nil, ErrFeedbackRecorded
+	}
+	exp.rated = true
+
+	stats := m.variantStats(scope, &exp.assignment)
+	stats.feedback++
+	if positive {
+		stats.positiveFeedback++
+	}
+
+	assignment := exp.assignment
+	return &assignment, nil
+}
+
+// variantStats retorna as métricas da variante no escopo, criando-as no primeiro uso
+func (m *Manager) variantStats(scope string, assignment *Assignment) *variantStats {
+	key := statsKey{scope: scope, experimentID: assignment.ExperimentID, variantID: assignment.Variant.ID}
+	stats, ok := m.stats[key]
+	if !ok {
+		stats = &variantStats{}
+		m.stats[key] = stats
+	}
+	return stats
+}
+
+// Results retorna as métricas acumuladas de todos os experimentos no escopo informado
+func (m *Manager) Results(scope string) []Result {
+	if m == nil {
+		return []Result{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	results := make([]Result, 0, len(m.experiments))
+	for _, exp := range m.experiments {
+		result := Result{
+			ExperimentID: exp.ID,
+			Description:  exp.Description,
+			Enabled:      exp.Enabled,
+		}
+
+		for _, variant := range exp.Variants {
+			stats, ok := m.stats[statsKey{scope: scope, experimentID: exp.ID, variantID: variant.ID}]
+			if !ok {
+				stats = &variantStats{}
+			}
+			variantResult := VariantResult{
+				VariantID:        variant.ID,
+				Weight:           variant.Weight,
+				Requests:         stats.requests,
+				Feedback:         stats.feedback,
+				PositiveFeedback: stats.positiveFeedback,
+			}
+			if stats.requests > 0 {
+				variantResult.AvgLatencyMs = float64(stats.totalLatencyMs) / float64(stats.requests)
+				variantResult.AvgTotalTokens = float64(stats.totalTokens) / float64(stats.requests)
+			}
+			if stats.feedback > 0 {
+				variantResult.PositiveRate = float64(stats.positiveFeedback) / float64(stats.feedback)
+			}
+			result.Variants = append(result.Variants, variantResult)
+		}
+
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ExperimentID < results[j].ExperimentID
+	})
+	return results
+}
+
+// purgeExposures remove, no máximo uma vez por minuto, as exposições que não podem mais receber feedback
+func (m *Manager) purgeExposures(now time.Time) {
+	if now.Sub(m.lastPurge) < time.Minute {
+		return
+	}
+	m.lastPurge = now
+
+	for id, exp := range m.exposures {
+		if now.Sub(exp.at) > exposureTTL {
+			delete(m.exposures, id)
+		}
+	}
+}
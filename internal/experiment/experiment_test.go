@@ -0,0 +1,250 @@
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newTestManager(t *testing.T, variants ...Variant) *Manager {
+	t.Helper()
+	m, err := NewManager([]Experiment{{ID: "prompt-v2", Enabled: true, Variants: variants}})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	return m
+}
+
+func TestNewManagerValidation(t *testing.T) {
+	variant := func(id string, weight int) Variant { return Variant{ID: id, Weight: weight} }
+	withParams := func(topK int, threshold float32, temperature *float32) []Experiment {
+		return []Experiment{{ID: "e", Variants: []Variant{{ID: "a", Weight: 1, TopK: topK, Threshold: threshold, Temperature: temperature}}}}
+	}
+	temperature := func(t float32) *float32 { return &t }
+
+	tests := []struct {
+		name        string
+		experiments []Experiment
+		wantErr     bool
+	}{
+		{name: "válido", experiments: []Experiment{{ID: "e", Enabled: true, Variants: []Variant{variant("a", 1), variant("b", 1)}}}},
+		{name: "sem id", experiments: []Experiment{{Variants: []Variant{variant("a", 1)}}}, wantErr: true},
+		{name: "sem variantes", experiments: []Experiment{{ID: "e"}}, wantErr: true},
+		{name: "variante sem id", experiments: []Experiment{{ID: "e", Variants: []Variant{variant("", 1)}}}, wantErr: true},
+		{name: "variante duplicada", experiments: []Experiment{{ID: "e", Variants: []Variant{variant("a", 1), variant("a", 1)}}}, wantErr: true},
+		{name: "peso negativo", experiments: []Experiment{{ID: "e", Variants: []Variant{variant("a", -1), variant("b", 2)}}}, wantErr: true},
+		{name: "soma dos pesos zero", experiments: []Experiment{{ID: "e", Variants: []Variant{variant("a", 0)}}}, wantErr: true},
+		{name: "parâmetros nos limites", experiments: withParams(100, 1, temperature(2))},
+		{name: "temperatura zero", experiments: withParams(10, 0.5, temperature(0))},
+		{name: "top_k negativo", experiments: withParams(-1, 0, nil), wantErr: true},
+		{name: "top_k acima de 100", experiments: withParams(101, 0, nil), wantErr: true},
+		{name: "threshold negativo", experiments: withParams(0, -0.1, nil), wantErr: true},
+		{name: "threshold acima de 1", experiments: withParams(0, 1.5, nil), wantErr: true},
+		{name: "temperatura negativa", experiments: withParams(0, 0, temperature(-0.5)), wantErr: true},
+		{name: "temperatura acima de 2", experiments: withParams(0, 0, temperature(2.5)), wantErr: true},
+		{
+			name: "experimento duplicado",
+			experiments: []Experiment{
+				{ID: "e", Variants: []Variant{variant("a", 1)}},
+				{ID: "e", Variants: []Variant{variant("a", 1)}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "dois experimentos habilitados",
+			experiments: []Experiment{
+				{ID: "e1", Enabled: true, Variants: []Variant{variant("a", 1)}},
+				{ID: "e2", Enabled: true, Variants: []Variant{variant("a", 1)}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewManager(tt.experiments)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewManager() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAssign(t *testing.T) {
+	const keys = 10000
+
+	tests := []struct {
+		name     string
+		variants []Variant
+		// wantShare é a fração esperada de chaves por variante
+		wantShare map[string]float64
+	}{
+		{
+			name:      "pesos iguais",
+			variants:  []Variant{{ID: "a", Weight: 1}, {ID: "b", Weight: 1}},
+			wantShare: map[string]float64{"a": 0.5, "b": 0.5},
+		},
+		{
+			name:      "pesos desiguais",
+			variants:  []Variant{{ID: "a", Weight: 90}, {ID: "b", Weight: 10}},
+			wantShare: map[string]float64{"a": 0.9, "b": 0.1},
+		},
+		{
+			name:      "variante com peso zero não recebe tráfego",
+			variants:  []Variant{{ID: "a", Weight: 0}, {ID: "b", Weight: 3}},
+			wantShare: map[string]float64{"a": 0, "b": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, tt.variants...)
+
+			counts := make(map[string]int)
+			for i := 0; i < keys; i++ {
+				key := fmt.Sprintf("usuario-%d", i)
+				assignment := m.Assign(key)
+				if assignment == nil {
+					t.Fatalf("Assign(%q) = nil", key)
+				}
+				if again := m.Assign(key); again.Variant.ID != assignment.Variant.ID {
+					t.Fatalf("Assign(%q) não é determinístico: %s e %s", key, assignment.Variant.ID, again.Variant.ID)
+				}
+				counts[assignment.Variant.ID]++
+			}
+
+			for id, want := range tt.wantShare {
+				got := float64(counts[id]) / keys
+				if got < want-0.03 || got > want+0.03 {
+					t.Errorf("variante %s recebeu %.3f do tráfego, want %.2f", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAssignWithoutActiveExperiment(t *testing.T) {
+	var nilManager *Manager
+	if got := nilManager.Assign("usuario"); got != nil {
+		t.Errorf("Assign() em gerenciador nil = %+v, want nil", got)
+	}
+
+	m, err := NewManager([]Experiment{{ID: "e", Variants: []Variant{{ID: "a", Weight: 1}}}})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if got := m.Assign("usuario"); got != nil {
+		t.Errorf("Assign() sem experimento habilitado = %+v, want nil", got)
+	}
+}
+
+func TestRecordFeedback(t *testing.T) {
+	tests := []struct {
+		name          string
+		exposureScope string
+		feedback      []string // escopo de cada feedback enviado, em ordem
+		wantErrs      []error
+		wantFound     []bool
+	}{
+		{
+			name:      "feedback no mesmo escopo",
+			feedback:  []string{""},
+			wantErrs:  []error{nil},
+			wantFound: []bool{true},
+		},
+		{
+			name:      "feedback repetido é ignorado",
+			feedback:  []string{"", ""},
+			wantErrs:  []error{nil, ErrFeedbackRecorded},
+			wantFound: []bool{true, false},
+		},
+		{
+			name:          "feedback de outro tenant não é atribuído",
+			exposureScope: "time-a",
+			feedback:      []string{"time-b", "time-a"},
+			wantErrs:      []error{nil, nil},
+			wantFound:     []bool{false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, Variant{ID: "a", Weight: 1})
+			assignment := m.Assign("usuario")
+			m.RecordExposure(tt.exposureScope, "q1", assignment, 100*time.Millisecond, Usage{TotalTokens: 50})
+
+			for i, scope := range tt.feedback {
+				got, err := m.RecordFeedback(scope, "q1", true)
+				if !errors.Is(err, tt.wantErrs[i]) {
+					t.Errorf("feedback %d: error = %v, want %v", i, err, tt.wantErrs[i])
+				}
+				if (got != nil) != tt.wantFound[i] {
+					t.Errorf("feedback %d: assignment = %+v, want encontrado %v", i, got, tt.wantFound[i])
+				}
+			}
+
+			variant := m.Results(tt.exposureScope)[0].Variants[0]
+			if variant.Requests != 1 || variant.Feedback != 1 || variant.PositiveFeedback != 1 {
+				t.Errorf("métricas = %+v, want 1 requisição e 1 feedback positivo", variant)
+			}
+		})
+	}
+}
+
+func TestResultsByScope(t *testing.T) {
+	m := newTestManager(t, Variant{ID: "a", Weight: 1})
+	assignment := m.Assign("usuario")
+
+	m.RecordExposure("time-a", "q1", assignment, 100*time.Millisecond, Usage{TotalTokens: 40})
+	m.RecordExposure("time-a", "q2", assignment, 300*time.Millisecond, Usage{TotalTokens: 60})
+	m.RecordExposure("time-b", "q3", assignment, 50*time.Millisecond, Usage{TotalTokens: 10})
+	m.RecordFeedback("time-a", "q1", true)
+	m.RecordFeedback("time-a", "q2", false)
+
+	tests := []struct {
+		scope        string
+		wantRequests int
+		wantLatency  float64
+		wantTokens   float64
+		wantRate     float64
+	}{
+		{scope: "time-a", wantRequests: 2, wantLatency: 200, wantTokens: 50, wantRate: 0.5},
+		{scope: "time-b", wantRequests: 1, wantLatency: 50, wantTokens: 10, wantRate: 0},
+		{scope: "time-c", wantRequests: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			variant := m.Results(tt.scope)[0].Variants[0]
+			if variant.Requests != tt.wantRequests || variant.AvgLatencyMs != tt.wantLatency ||
+				variant.AvgTotalTokens != tt.wantTokens || variant.PositiveRate != tt.wantRate {
+				t.Errorf("Results(%q) = %+v", tt.scope, variant)
+			}
+		})
+	}
+}
+
+func TestCheckPromptTemplates(t *testing.T) {
+	templates := map[string]bool{"default": true, "conciso": true}
+	exists := func(name string) bool { return templates[name] }
+
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{name: "template existente", template: "conciso"},
+		{name: "sem template", template: ""},
+		{name: "template inexistente", template: "detalhado", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, Variant{ID: "controle", Weight: 1}, Variant{ID: "b", Weight: 1, PromptTemplate: tt.template})
+			if err := m.CheckPromptTemplates(exists); (err != nil) != tt.wantErr {
+				t.Errorf("CheckPromptTemplates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
+)
+
+// Agent responde uma pergunta no modo agente, em que o modelo chama ferramentas de busca em vários
+// passos, e retorna a resposta com o rastro das chamadas
+func (h *Handler) Agent(c *gin.Context) {
+	var req models.AgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Erro ao fazer bind da requisição do agente")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Requisição inválida: " + err.Error()})
+		return
+	}
+
+	response, err := h.ragService.Agent(c.Request.Context(), req)
+	if errors.Is(err, tenant.ErrQuotaExceeded) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Erro ao executar agente")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
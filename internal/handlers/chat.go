@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marcopollivier/rag-go-ex01/internal/chat"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/rag"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
+)
+
+// Chat responde uma mensagem em uma sessão de conversa (criando a sessão quando não informada)
+func (h *Handler) Chat(c *gin.Context) {
+	var req models.ChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Erro ao fazer bind da requisição de chat")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Requisição inválida: " + err.Error()})
+		return
+	}
+
+	response, err := h.ragService.Chat(c.Request.Context(), req)
+	if errors.Is(err, tenant.ErrQuotaExceeded) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, chat.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sessão não encontrada ou expirada"})
+		return
+	}
+	if errors.Is(err, rag.ErrInvalidRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Erro ao processar mensagem de chat")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateChatSession cria uma sessão de conversa vazia
+func (h *Handler) CreateChatSession(c *gin.Context) {
+	var req struct {
+		TTLSeconds int `json:"ttl_seconds" binding:"omitempty,min=1"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Requisição inválida: " + err.Error()})
+			return
+		}
+	}
+
+	session := h.ragService.CreateChatSession(c.Request.Context(), time.Duration(req.TTLSeconds)*time.Second)
+	c.JSON(http.StatusCreated, session)
+}
+
+// ListChatSessions lista as sessões de conversa ativas
+func (h *Handler) ListChatSessions(c *gin.Context) {
+	sessions := h.ragService.ListChatSessions(c.Request.Context())
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+		"count":    len(sessions),
+	})
+}
+
+// GetChatSession retorna uma sessão de conversa com seu histórico
+func (h *Handler) GetChatSession(c *gin.Context) {
+	session, err := h.ragService.GetChatSession(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sessão não encontrada ou expirada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// DeleteChatSession remove uma sessão de conversa
+func (h *Handler) DeleteChatSession(c *gin.Context) {
+	if err := h.ragService.DeleteChatSession(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sessão não encontrada ou expirada"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/qdrant"
+	"github.com/marcopollivier/rag-go-ex01/internal/rag"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminMiddleware recusa requisições sem a chave de administração (X-API-Key ou Authorization: Bearer)
+func AdminMiddleware(adminKey string, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := requestAPIKey(c)
+		if subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) != 1 {
+			logger.Warnf("Requisição administrativa sem chave válida: %s %s", c.Request.Method, c.FullPath())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Chave de administração ausente ou inválida"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// DescribeKnowledgeBase retorna a configuração e o estado da coleção de uma base de conhecimento
+func (h *Handler) DescribeKnowledgeBase(c *gin.Context) {
+	description, err := h.ragService.DescribeKnowledgeBase(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		h.collectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, description)
+}
+
+// CreateKnowledgeBase cria uma base de conhecimento com uma coleção nova
+func (h *Handler) CreateKnowledgeBase(c *gin.Context) {
+	var req models.CreateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Erro ao fazer bind da criação de coleção")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Requisição inválida: " + err.Error()})
+		return
+	}
+
+	description, err := h.ragService.CreateKnowledgeBase(c.Request.Context(), req)
+	if err != nil {
+		h.collectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, description)
+}
+
+// UpdateKnowledgeBase altera a descrição e os parâmetros ajustáveis de uma base de conhecimento
+func (h *Handler) UpdateKnowledgeBase(c *gin.Context) {
+	var req models.UpdateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Erro ao fazer bind da atualização de coleção")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Requisição inválida: " + err.Error()})
+		return
+	}
+
+	description, err := h.ragService.UpdateKnowledgeBase(c.Request.Context(), c.Param("name"), req)
+	if err != nil {
+		h.collectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, description)
+}
+
+// DropKnowledgeBase remove uma base de conhecimento e apaga sua coleção
+func (h *Handler) DropKnowledgeBase(c *gin.Context) {
+	name := c.Param("name")
+	if err := h.ragService.DropKnowledgeBase(c.Request.Context(), name); err != nil {
+		h.collectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Base de conhecimento removida com sucesso",
+		"name":    name,
+	})
+}
+
+// collectionError responde aos erros da gestão de coleções com o status correspondente
+func (h *Handler) collectionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, rag.ErrKnowledgeBaseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, rag.ErrKnowledgeBaseExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, rag.ErrInvalidRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.WithError(err).Error("Erro na gestão de coleções")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+	}
+}
+
+// ExportCollection exporta os pontos da coleção de uma base em JSONL (ID, payload e, com
+// vectors=true, o vetor), percorrendo todas as páginas da coleção. A primeira linha é o cabeçalho
+// com a descrição da base e os parâmetros dos vetores.
+func (h *Handler) ExportCollection(c *gin.Context) {
+	name := c.Param("name")
+	withVectors := c.Query("vectors") == "true"
+	h.logger.Infof("Exportando coleção da base '%s'", name)
+
+	description, err := h.ragService.DescribeKnowledgeBase(c.Request.Context(), name)
+	if err != nil {
+		h.collectionError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".jsonl"))
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	header := models.CollectionHeader{
+		Name:        description.Name,
+		Description: description.Description,
+		VectorSize:  description.VectorSize,
+		Distance:    description.Distance,
+	}
+	if err := encoder.Encode(gin.H{"collection": header}); err != nil {
+		h.logger.WithError(err).Error("Erro ao escrever cabeçalho da exportação")
+		return
+	}
+
+	exported := 0
+	err = h.ragService.ExportCollection(c.Request.Context(), name, withVectors, func(record models.PointRecord) error {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+		exported++
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		// Os cabeçalhos já foram enviados; o cliente percebe a falha pelo stream truncado
+		h.logger.WithError(err).Errorf("Erro ao exportar coleção após %d registros", exported)
+		return
+	}
+
+	h.logger.Infof("Exportação da coleção concluída com %d registros", exported)
+}
+
+// ImportCollection importa pontos em JSONL para a coleção de uma base, sem gerar embeddings para
+// os registros que trazem o vetor. A base precisa existir; criá-la na importação exige a rota de
+// administração.
+func (h *Handler) ImportCollection(c *gin.Context) {
+	if c.Query("create") == "true" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "create=true só é aceito em /admin/collections/:name/import"})
+		return
+	}
+	h.importCollection(c, models.ImportOptions{})
+}
+
+// AdminImportCollection importa pontos em JSONL como ImportCollection; com create=true, cria a base
+// quando ela não existe, com a dimensão e a distância do arquivo (distance substitui a do arquivo)
+func (h *Handler) AdminImportCollection(c *gin.Context) {
+	h.importCollection(c, models.ImportOptions{
+		CreateIfMissing: c.Query("create") == "true",
+		Distance:        c.Query("distance"),
+	})
+}
+
+func (h *Handler) importCollection(c *gin.Context, opts models.ImportOptions) {
+	response, err := h.ragService.ImportCollection(c.Request.Context(), c.Param("name"), c.Request.Body, opts)
+	if err == nil {
+		status := http.StatusOK
+		if response.Created {
+			status = http.StatusCreated
+		}
+		c.JSON(status, response)
+		return
+	}
+
+	status := http.StatusInternalServerError
+	message := "Erro interno do servidor"
+	switch {
+	case errors.Is(err, rag.ErrKnowledgeBaseNotFound):
+		status, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, rag.ErrInvalidRequest):
+		status, message = http.StatusBadRequest, err.Error()
+	case errors.Is(err, tenant.ErrQuotaExceeded):
+		status, message = http.StatusTooManyRequests, err.Error()
+	case errors.Is(err, qdrant.ErrForeignPoint), errors.Is(err, rag.ErrKnowledgeBaseExists):
+		status, message = http.StatusConflict, err.Error()
+	default:
+		h.logger.WithError(err).Error("Erro ao importar coleção")
+	}
+
+	// Lotes gravados antes do erro permanecem na coleção; a resposta informa quantos foram
+	c.JSON(status, gin.H{
+		"error":  message,
+		"result": response,
+	})
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/qdrant"
+	"github.com/marcopollivier/rag-go-ex01/internal/rag"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
+)
+
+// GetDocument retorna um documento pelo ID
+func (h *Handler) GetDocument(c *gin.Context) {
+	doc, err := h.ragService.GetDocument(c.Request.Context(), c.Query("collection"), c.Param("id"))
+	if err != nil {
+		h.documentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
+
+// UpdateDocument altera fonte, metadados ou conteúdo de um documento
+func (h *Handler) UpdateDocument(c *gin.Context) {
+	var req models.UpdateDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Erro ao fazer bind da atualização de documento")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Requisição inválida: " + err.Error()})
+		return
+	}
+
+	response, err := h.ragService.UpdateDocument(c.Request.Context(), c.Query("collection"), c.Param("id"), req)
+	if err != nil {
+		h.documentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteDocument remove um documento e os seus trechos
+func (h *Handler) DeleteDocument(c *gin.Context) {
+	docID := c.Param("id")
+	deleted, err := h.ragService.DeleteDocument(c.Request.Context(), c.Query("collection"), docID)
+	if err != nil {
+		h.documentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Documento removido com sucesso",
+		"id":            docID,
+		"deleted_count": deleted,
+	})
+}
+
+// documentError responde aos erros das operações sobre um documento com o status correspondente
+func (h *Handler) documentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, qdrant.ErrDocumentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Documento não encontrado"})
+	case errors.Is(err, rag.ErrInvalidRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, tenant.ErrQuotaExceeded):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	default:
+		h.logger.WithError(err).Error("Erro na operação sobre o documento")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+	}
+}
+
+// DeleteByFilter remove todos os documentos que atendem ao filtro (ou apenas os conta, em dry run)
+func (h *Handler) DeleteByFilter(c *gin.Context) {
+	var req models.DeleteByFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Erro ao fazer bind da remoção por filtro")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Requisição inválida: " + err.Error()})
+		return
+	}
+
+	response, err := h.ragService.DeleteByFilter(c.Request.Context(), req)
+	if err != nil {
+		h.documentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateByFilter altera fonte e metadados de todos os documentos que atendem ao filtro (ou apenas
+// os conta, em dry run)
+func (h *Handler) UpdateByFilter(c *gin.Context) {
+	var req models.UpdateByFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Erro ao fazer bind da alteração por filtro")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Requisição inválida: " + err.Error()})
+		return
+	}
+
+	response, err := h.ragService.UpdateByFilter(c.Request.Context(), req)
+	if err != nil {
+		h.documentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marcopollivier/rag-go-ex01/internal/experiment"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+// Feedback registra a avaliação do usuário sobre uma resposta, identificada pelo query_id
+func (h *Handler) Feedback(c *gin.Context) {
+	var req models.FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Erro ao fazer bind do feedback")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Requisição inválida: " + err.Error()})
+		return
+	}
+
+	response, err := h.ragService.RecordFeedback(c.Request.Context(), req)
+	if errors.Is(err, experiment.ErrFeedbackRecorded) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Feedback já registrado para esta consulta"})
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Erro ao registrar feedback")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ExperimentResults retorna as métricas por variante de cada experimento configurado, no tenant da requisição
+func (h *Handler) ExperimentResults(c *gin.Context) {
+	results := h.ragService.ExperimentResults(c.Request.Context())
+	c.JSON(http.StatusOK, gin.H{
+		"experiments": results,
+		"count":       len(results),
+	})
+}
@@ -1,24 +1,40 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/marcopollivier/rag-go-ex01/internal/language"
 	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/qdrant"
 	"github.com/marcopollivier/rag-go-ex01/internal/rag"
+	"github.com/marcopollivier/rag-go-ex01/internal/rerank"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
 	"github.com/sirupsen/logrus"
 )
 
+// maxPageLimit é o maior tamanho de página aceito na listagem de documentos
+const maxPageLimit = 1000
+
 type Handler struct {
 	ragService *rag.Service
+	wsUpgrader websocket.Upgrader
 	logger     *logrus.Logger
 }
 
-// NewHandler cria um novo handler
-func NewHandler(ragService *rag.Service, logger *logrus.Logger) *Handler {
+// NewHandler cria um novo handler. wsAllowedOrigins lista as origens aceitas no WebSocket de chat;
+// vazia, só a própria origem do servidor é aceita.
+func NewHandler(ragService *rag.Service, wsAllowedOrigins []string, logger *logrus.Logger) *Handler {
 	return &Handler{
 		ragService: ragService,
+		wsUpgrader: newWSUpgrader(wsAllowedOrigins),
 		logger:     logger,
 	}
 }
@@ -33,6 +49,14 @@ func (h *Handler) Query(c *gin.Context) {
 	}
 
 	response, err := h.ragService.Query(c.Request.Context(), req)
+	if errors.Is(err, tenant.ErrQuotaExceeded) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, rag.ErrInvalidRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("Erro ao processar query")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
@@ -42,6 +66,87 @@ func (h *Handler) Query(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// QueryStream executa uma consulta RAG respondendo com Server-Sent Events: "documents" com os documentos
+// recuperados, "token" para cada trecho da resposta e "done" com tempos e consumo ("error" em caso de falha).
+// Erros anteriores ao primeiro evento, como validação e cota, são respondidos com o status HTTP correspondente.
+func (h *Handler) QueryStream(c *gin.Context) {
+	var req models.QueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Erro ao fazer bind da requisição")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Requisição inválida: " + err.Error()})
+		return
+	}
+
+	streaming := false
+	err := h.ragService.QueryStream(c.Request.Context(), req, func(event string, data interface{}) error {
+		if !streaming {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Header("X-Accel-Buffering", "no")
+			streaming = true
+		}
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+		return c.Request.Context().Err()
+	})
+	if err == nil {
+		return
+	}
+
+	userError := errors.Is(err, rag.ErrInvalidRequest) || errors.Is(err, tenant.ErrQuotaExceeded)
+	if !userError && c.Request.Context().Err() == nil {
+		h.logger.WithError(err).Error("Erro ao processar query em streaming")
+	}
+
+	if !streaming {
+		switch {
+		case errors.Is(err, tenant.ErrQuotaExceeded):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		case errors.Is(err, rag.ErrInvalidRequest):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+		}
+		return
+	}
+
+	if userError {
+		c.SSEvent("error", gin.H{"error": err.Error()})
+		c.Writer.Flush()
+	} else if c.Request.Context().Err() == nil {
+		c.SSEvent("error", gin.H{"error": "Erro interno do servidor"})
+		c.Writer.Flush()
+	}
+}
+
+// Search recupera documentos ranqueados sem gerar resposta
+func (h *Handler) Search(c *gin.Context) {
+	var req models.SearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Erro ao fazer bind da requisição de busca")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Requisição inválida: " + err.Error()})
+		return
+	}
+
+	response, err := h.ragService.Search(c.Request.Context(), req)
+	if errors.Is(err, tenant.ErrQuotaExceeded) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, rag.ErrInvalidRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Erro ao processar busca")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // IndexDocuments indexa documentos
 func (h *Handler) IndexDocuments(c *gin.Context) {
 	var req models.IndexRequest
@@ -51,7 +156,19 @@ func (h *Handler) IndexDocuments(c *gin.Context) {
 		return
 	}
 
-	response, err := h.ragService.IndexDocuments(c.Request.Context(), req.Documents)
+	response, err := h.ragService.IndexDocuments(c.Request.Context(), req.Collection, req.Documents)
+	if errors.Is(err, tenant.ErrQuotaExceeded) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, qdrant.ErrForeignPoint) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, rag.ErrInvalidRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("Erro ao indexar documentos")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
@@ -65,7 +182,11 @@ func (h *Handler) IndexDocuments(c *gin.Context) {
 func (h *Handler) IndexSampleData(c *gin.Context) {
 	h.logger.Info("Indexando dados de exemplo")
 
-	response, err := h.ragService.IndexTextFiles(c.Request.Context(), "./documents")
+	response, err := h.ragService.IndexSampleData(c.Request.Context())
+	if errors.Is(err, tenant.ErrQuotaExceeded) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("Erro ao indexar dados de exemplo")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
@@ -86,25 +207,97 @@ func (h *Handler) QuickQuery(c *gin.Context) {
 	// Parâmetros opcionais
 	topK := 5 // O número máximo de documentos relevantes que devem ser retornados
 	if topKStr := c.Query("top_k"); topKStr != "" {
-		if k, err := strconv.Atoi(topKStr); err == nil {
-			topK = k
+		k, err := strconv.Atoi(topKStr)
+		if err != nil || k < 1 || k > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'top_k' deve ser um inteiro entre 1 e 100"})
+			return
 		}
+		topK = k
 	}
 
 	threshold := float32(0.7) // O score mínimo de similaridade que um documento deve ter para ser considerado relevante
 	if thresholdStr := c.Query("threshold"); thresholdStr != "" {
-		if t, err := strconv.ParseFloat(thresholdStr, 32); err == nil {
-			threshold = float32(t)
+		t, err := strconv.ParseFloat(thresholdStr, 32)
+		if err != nil || t < 0 || t > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'threshold' deve ser um número entre 0 e 1"})
+			return
 		}
+		threshold = float32(t)
 	}
 
 	req := models.QueryRequest{
-		Query:     query,
-		TopK:      topK,
-		Threshold: threshold,
+		Query:          query,
+		TopK:           topK,
+		Threshold:      threshold,
+		Reranker:       c.Query("reranker"), // "lexical" ou "llm"
+		MMR:            c.Query("mmr") == "true",
+		MultiQuery:     c.Query("multi_query") == "true",
+		RetrievalMode:  c.Query("retrieval_mode"),
+		AnswerPolicy:   c.Query("answer_policy"),
+		Language:       c.Query("language"),
+		PromptTemplate: c.Query("prompt_template"),
+		Collections:    splitIDs(c.Query("collections")),
+		Routing:        c.Query("routing"),
+	}
+	req.Source = c.Query("source")
+
+	if lambdaStr := c.Query("mmr_lambda"); lambdaStr != "" {
+		if l, err := strconv.ParseFloat(lambdaStr, 32); err == nil && l >= 0 && l <= 1 {
+			lambda := float32(l)
+			req.MMRLambda = &lambda
+		}
+	}
+
+	if req.Reranker != "" && req.Reranker != rerank.Lexical && req.Reranker != rerank.LLM {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'reranker' deve ser 'lexical' ou 'llm'"})
+		return
+	}
+
+	var err error
+	if req.CreatedAfter, err = parseTimeParam(c, "created_after"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.CreatedBefore, err = parseTimeParam(c, "created_before"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch req.AnswerPolicy {
+	case "", models.AnswerPolicyGroundedOnly, models.AnswerPolicyGroundedPreferred, models.AnswerPolicyOpen:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'answer_policy' deve ser 'grounded_only', 'grounded_preferred' ou 'open'"})
+		return
+	}
+
+	switch req.RetrievalMode {
+	case "", models.RetrievalModeEmbedding, models.RetrievalModeHyDE, models.RetrievalModeHyDEHybrid:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'retrieval_mode' deve ser 'embedding', 'hyde' ou 'hyde_hybrid'"})
+		return
+	}
+
+	switch req.Routing {
+	case "", models.RoutingEmbedding, models.RoutingLLM, models.RoutingAll:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'routing' deve ser 'embedding', 'llm' ou 'all'"})
+		return
+	}
+
+	if req.Language != "" && req.Language != "auto" && !language.IsSupported(req.Language) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'language' deve ser 'auto', 'pt-BR', 'en' ou 'es'"})
+		return
 	}
 
 	response, err := h.ragService.Query(c.Request.Context(), req)
+	if errors.Is(err, tenant.ErrQuotaExceeded) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, rag.ErrInvalidRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("Erro ao processar quick query")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
@@ -121,12 +314,23 @@ func (h *Handler) GetAllDocuments(c *gin.Context) {
 	// Parâmetro opcional para limite
 	limit := 100
 	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil {
-			limit = l
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > maxPageLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Parâmetro 'limit' deve ser um inteiro entre 1 e %d", maxPageLimit)})
+			return
 		}
+		limit = l
 	}
 
-	documents, err := h.ragService.GetAllDocuments(c.Request.Context(), limit)
+	documents, nextCursor, err := h.ragService.GetAllDocuments(c.Request.Context(), c.Query("collection"), limit, c.Query("cursor"))
+	if errors.Is(err, rag.ErrInvalidRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, qdrant.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'cursor' inválido"})
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("Erro ao buscar todos os documentos")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
@@ -134,8 +338,9 @@ func (h *Handler) GetAllDocuments(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"documents": documents,
-		"count":     len(documents),
+		"documents":   documents,
+		"count":       len(documents),
+		"next_cursor": nextCursor,
 	})
 }
 
@@ -152,12 +357,23 @@ func (h *Handler) GetDocumentsBySource(c *gin.Context) {
 	// Parâmetro opcional para limite
 	limit := 100
 	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil {
-			limit = l
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > maxPageLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Parâmetro 'limit' deve ser um inteiro entre 1 e %d", maxPageLimit)})
+			return
 		}
+		limit = l
 	}
 
-	documents, err := h.ragService.GetDocumentsBySource(c.Request.Context(), source, limit)
+	documents, nextCursor, err := h.ragService.GetDocumentsBySource(c.Request.Context(), c.Query("collection"), source, limit, c.Query("cursor"))
+	if errors.Is(err, rag.ErrInvalidRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, qdrant.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'cursor' inválido"})
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("Erro ao buscar documentos por fonte")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
@@ -165,17 +381,124 @@ func (h *Handler) GetDocumentsBySource(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"source":    source,
+		"source":      source,
+		"documents":   documents,
+		"count":       len(documents),
+		"next_cursor": nextCursor,
+	})
+}
+
+// GetSimilarDocuments recomenda documentos relacionados a um documento existente
+func (h *Handler) GetSimilarDocuments(c *gin.Context) {
+	docID := c.Param("id")
+	h.logger.Infof("Buscando documentos semelhantes a: %s", docID)
+
+	limit := 5
+	if limitStr := c.Query("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'limit' deve ser um inteiro entre 1 e 100"})
+			return
+		}
+		limit = l
+	}
+
+	threshold := float32(0)
+	if thresholdStr := c.Query("threshold"); thresholdStr != "" {
+		t, err := strconv.ParseFloat(thresholdStr, 32)
+		if err != nil || t < 0 || t > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'threshold' deve ser um número entre 0 e 1"})
+			return
+		}
+		threshold = float32(t)
+	}
+
+	positive := splitIDs(c.Query("positive"))
+	negative := splitIDs(c.Query("negative"))
+
+	documents, err := h.ragService.FindSimilar(c.Request.Context(), c.Query("collection"), docID, positive, negative, limit, threshold)
+	if errors.Is(err, rag.ErrInvalidRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, qdrant.ErrDocumentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Documento não encontrado"})
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Erro ao buscar documentos semelhantes")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":        docID,
 		"documents": documents,
 		"count":     len(documents),
 	})
 }
 
+// splitIDs separa uma lista de IDs delimitada por vírgulas, ignorando entradas vazias
+func splitIDs(value string) []string {
+	var ids []string
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// ExportDocuments exporta todos os documentos em NDJSON, percorrendo todas as páginas da coleção
+func (h *Handler) ExportDocuments(c *gin.Context) {
+	source := c.Query("source")
+	h.logger.Infof("Exportando documentos (fonte: '%s')", source)
+
+	encoder := json.NewEncoder(c.Writer)
+	exported := 0
+	err := h.ragService.ExportDocuments(c.Request.Context(), c.Query("collection"), source, func(doc models.Document) error {
+		if exported == 0 {
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Status(http.StatusOK)
+		}
+		if err := encoder.Encode(doc); err != nil {
+			return err
+		}
+		exported++
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil && exported == 0 {
+		if errors.Is(err, rag.ErrInvalidRequest) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.WithError(err).Error("Erro ao exportar documentos")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+		return
+	}
+	if err != nil {
+		// Os cabeçalhos já foram enviados; o cliente percebe a falha pelo stream truncado
+		h.logger.WithError(err).Errorf("Erro ao exportar documentos após %d registros", exported)
+		return
+	}
+	if exported == 0 {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+	}
+
+	h.logger.Infof("Exportação concluída com %d documentos", exported)
+}
+
 // GetCollectionInfo retorna informações sobre a coleção Qdrant
 func (h *Handler) GetCollectionInfo(c *gin.Context) {
 	h.logger.Info("Obtendo informações da coleção")
 
-	info, err := h.ragService.GetCollectionInfo(c.Request.Context())
+	info, err := h.ragService.GetCollectionInfo(c.Request.Context(), c.Query("collection"))
+	if errors.Is(err, rag.ErrInvalidRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("Erro ao obter informações da coleção")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
@@ -184,3 +507,35 @@ func (h *Handler) GetCollectionInfo(c *gin.Context) {
 
 	c.JSON(http.StatusOK, info)
 }
+
+// parseTimeParam lê um query parameter opcional no formato RFC3339
+func parseTimeParam(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("Parâmetro '%s' deve estar no formato RFC3339", name)
+	}
+	return &t, nil
+}
+
+// ListPromptTemplates lista os templates de prompt carregados, com nome, versão e idiomas
+func (h *Handler) ListPromptTemplates(c *gin.Context) {
+	templates := h.ragService.ListPromptTemplates()
+	c.JSON(http.StatusOK, gin.H{
+		"templates": templates,
+		"count":     len(templates),
+	})
+}
+
+// ListKnowledgeBases lista as bases de conhecimento com nome, descrição e coleção
+func (h *Handler) ListKnowledgeBases(c *gin.Context) {
+	bases := h.ragService.ListKnowledgeBases(c.Request.Context())
+	c.JSON(http.StatusOK, gin.H{
+		"collections": bases,
+		"count":       len(bases),
+	})
+}
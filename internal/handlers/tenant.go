@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
+	"github.com/sirupsen/logrus"
+)
+
+// Cabeçalhos que identificam o tenant da requisição
+const (
+	apiKeyHeader = "X-API-Key"
+	tenantHeader = "X-Tenant-ID"
+)
+
+// TenantMiddleware resolve o tenant de cada requisição pela chave de API (X-API-Key ou
+// Authorization: Bearer) e o associa ao contexto; requisições sem tenant válido são recusadas.
+// Com trustHeader, o cabeçalho X-Tenant-ID também é aceito, para implantações atrás de um
+// gateway que já autenticou o cliente.
+func TenantMiddleware(registry *tenant.Registry, trustHeader bool, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := requestAPIKey(c)
+		t, ok := registry.ByAPIKey(key)
+		if !ok && key == "" && trustHeader {
+			t, ok = registry.ByID(c.GetHeader(tenantHeader))
+		}
+		if !ok {
+			logger.Warnf("Requisição sem tenant válido: %s %s", c.Request.Method, c.FullPath())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Chave de API ausente ou inválida"})
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithContext(c.Request.Context(), t))
+		c.Next()
+	}
+}
+
+// requestAPIKey lê a chave de API do cabeçalho X-API-Key, de Authorization: Bearer ou, no handshake
+// WebSocket, do subprotocolo "apikey.<chave>"
+func requestAPIKey(c *gin.Context) string {
+	if key := c.GetHeader(apiKeyHeader); key != "" {
+		return key
+	}
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		return wsProtocolAPIKey(c.Request)
+	}
+	return ""
+}
+
+// TenantUsage retorna o tenant da requisição, suas cotas e o consumo do dia
+func (h *Handler) TenantUsage(c *gin.Context) {
+	t, usage := h.ragService.TenantUsage(c.Request.Context())
+	if t == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tenants não configurados"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":        t.ID,
+		"name":      t.Name,
+		"isolation": t.Isolation,
+		"quotas":    t.Quotas,
+		"usage":     usage,
+	})
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/marcopollivier/rag-go-ex01/internal/chat"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/rag"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
+)
+
+const (
+	// wsWriteWait é o tempo máximo para escrever uma mensagem no socket
+	wsWriteWait = 10 * time.Second
+	// wsPongWait é o tempo máximo sem receber pong (ou qualquer mensagem) do cliente
+	wsPongWait = 60 * time.Second
+	// wsPingPeriod é o intervalo dos pings de heartbeat enviados pelo servidor
+	wsPingPeriod = (wsPongWait * 9) / 10
+	// wsMaxMessageSize limita o tamanho das mensagens recebidas
+	wsMaxMessageSize = 64 * 1024
+	// wsSendBuffer é a fila de saída; quando cheia, a geração aguarda o cliente consumir (backpressure)
+	wsSendBuffer = 64
+	// wsQueueFullReason é o motivo de fechamento quando a fila de saída não comporta uma resposta de controle
+	wsQueueFullReason = "fila de saída cheia"
+	// wsMaxInFlight limita as gerações simultâneas por conexão
+	wsMaxInFlight = 4
+)
+
+const (
+	// wsSubprotocol é o subprotocolo do chat, devolvido ao cliente no handshake
+	wsSubprotocol = "rag-chat"
+	// wsAPIKeyProtocolPrefix marca o subprotocolo que carrega a chave de API. Navegadores não enviam
+	// cabeçalhos próprios no handshake, então a chave segue em Sec-WebSocket-Protocol.
+	wsAPIKeyProtocolPrefix = "apikey."
+)
+
+// newWSUpgrader cria o upgrader do chat. Aceita requisições sem Origin (clientes fora do navegador), da
+// mesma origem do servidor e das origens informadas.
+func newWSUpgrader(allowedOrigins []string) websocket.Upgrader {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		Subprotocols:    []string{wsSubprotocol},
+	}
+	if len(allowedOrigins) == 0 {
+		return upgrader
+	}
+
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+	upgrader.CheckOrigin = func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || allowed[strings.ToLower(origin)] {
+			return true
+		}
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+	return upgrader
+}
+
+// wsProtocolAPIKey lê a chave de API enviada como subprotocolo "apikey.<chave>" no handshake
+func wsProtocolAPIKey(r *http.Request) string {
+	for _, protocol := range websocket.Subprotocols(r) {
+		if strings.HasPrefix(protocol, wsAPIKeyProtocolPrefix) {
+			return strings.TrimPrefix(protocol, wsAPIKeyProtocolPrefix)
+		}
+	}
+	return ""
+}
+
+// wsConnection mantém o estado de uma conexão WebSocket de chat
+type wsConnection struct {
+	conn  *websocket.Conn
+	send  chan models.WSServerMessage
+	ctx   context.Context
+	close context.CancelFunc
+	// closeReason é definido antes de close quando a conexão é encerrada pelo servidor (protegido por mu)
+	closeReason string
+	mu          sync.Mutex
+	inFlight    map[string]context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// ChatWebSocket abre uma conexão WebSocket de chat. O protocolo está descrito em docs/websocket-protocol.md.
+func (h *Handler) ChatWebSocket(c *gin.Context) {
+	conn, err := h.wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.logger.WithError(err).Error("Erro ao abrir conexão WebSocket")
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	ws := &wsConnection{
+		conn:     conn,
+		send:     make(chan models.WSServerMessage, wsSendBuffer),
+		ctx:      ctx,
+		close:    cancel,
+		inFlight: make(map[string]context.CancelFunc),
+	}
+
+	h.logger.Info("Conexão WebSocket de chat aberta")
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		defer cancel()
+		if err := ws.writeLoop(); err != nil {
+			h.logger.WithError(err).Debug("Escrita no WebSocket encerrada")
+		}
+	}()
+
+	h.wsReadLoop(ws)
+
+	// Encerrar gerações pendentes antes de liberar a conexão
+	cancel()
+	ws.cancelAll()
+	ws.wg.Wait()
+	<-writerDone
+
+	h.logger.Info("Conexão WebSocket de chat encerrada")
+}
+
+// wsReadLoop lê e despacha as mensagens do cliente até a conexão ser encerrada
+func (h *Handler) wsReadLoop(ws *wsConnection) {
+	ws.conn.SetReadLimit(wsMaxMessageSize)
+	ws.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	ws.conn.SetPongHandler(func(string) error {
+		return ws.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		var msg models.WSClientMessage
+		if err := ws.conn.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				h.logger.WithError(err).Warn("Conexão WebSocket encerrada inesperadamente")
+			}
+			return
+		}
+		ws.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		switch msg.Type {
+		case models.WSTypeMessage:
+			h.wsStartGeneration(ws, msg)
+		case models.WSTypeCancel:
+			if !ws.cancel(msg.RequestID) {
+				ws.reply(models.WSServerMessage{Type: models.WSTypeError, RequestID: msg.RequestID, Error: "Requisição não encontrada ou já concluída"})
+			}
+		case models.WSTypePing:
+			ws.reply(models.WSServerMessage{Type: models.WSTypePong, RequestID: msg.RequestID})
+		default:
+			ws.reply(models.WSServerMessage{Type: models.WSTypeError, RequestID: msg.RequestID, Error: "Tipo de mensagem desconhecido: " + msg.Type})
+		}
+		if ws.ctx.Err() != nil {
+			h.logger.Warn("Fila de saída do WebSocket cheia; encerrando a conexão")
+			return
+		}
+	}
+}
+
+// wsStartGeneration valida a mensagem e inicia a geração em streaming em uma goroutine própria
+func (h *Handler) wsStartGeneration(ws *wsConnection, msg models.WSClientMessage) {
+	if msg.RequestID == "" {
+		msg.RequestID = uuid.New().String()
+	}
+
+	if err := binding.Validator.ValidateStruct(&msg.ChatRequest); err != nil {
+		ws.reply(models.WSServerMessage{Type: models.WSTypeError, RequestID: msg.RequestID, Error: "Requisição inválida: " + err.Error()})
+		return
+	}
+
+	reqCtx, reqCancel := context.WithCancel(ws.ctx)
+	if errMsg := ws.register(msg.RequestID, reqCancel); errMsg != "" {
+		reqCancel()
+		ws.reply(models.WSServerMessage{Type: models.WSTypeError, RequestID: msg.RequestID, Error: errMsg})
+		return
+	}
+
+	ws.wg.Add(1)
+	go func() {
+		defer ws.wg.Done()
+		defer ws.unregister(msg.RequestID)
+		defer reqCancel()
+
+		err := h.ragService.ChatStream(reqCtx, msg.ChatRequest, func(event string, data interface{}) error {
+			return ws.enqueue(reqCtx, models.WSServerMessage{Type: event, RequestID: msg.RequestID, Data: data})
+		})
+
+		switch {
+		case err == nil:
+		case reqCtx.Err() != nil:
+			h.logger.Infof("Geração %s cancelada", msg.RequestID)
+			ws.enqueue(ws.ctx, models.WSServerMessage{Type: models.WSTypeCancelled, RequestID: msg.RequestID})
+		case errors.Is(err, rag.ErrInvalidRequest), errors.Is(err, tenant.ErrQuotaExceeded):
+			ws.enqueue(ws.ctx, models.WSServerMessage{Type: models.WSTypeError, RequestID: msg.RequestID, Error: err.Error()})
+		case errors.Is(err, chat.ErrSessionNotFound):
+			ws.enqueue(ws.ctx, models.WSServerMessage{Type: models.WSTypeError, RequestID: msg.RequestID, Error: "Sessão não encontrada ou expirada"})
+		default:
+			h.logger.WithError(err).Error("Erro ao processar mensagem no WebSocket")
+			ws.enqueue(ws.ctx, models.WSServerMessage{Type: models.WSTypeError, RequestID: msg.RequestID, Error: "Erro interno do servidor"})
+		}
+	}()
+}
+
+// writeLoop é o único escritor do socket: envia as mensagens da fila e os pings de heartbeat
+func (ws *wsConnection) writeLoop() error {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-ws.send:
+			ws.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := ws.conn.WriteJSON(msg); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			ws.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := ws.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return err
+			}
+		case <-ws.ctx.Done():
+			reason := ws.reason()
+			code := websocket.CloseNormalClosure
+			if reason != "" {
+				code = websocket.CloseTryAgainLater
+			}
+			ws.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteWait))
+			return nil
+		}
+	}
+}
+
+// enqueue coloca uma mensagem na fila de saída, bloqueando enquanto ela estiver cheia
+func (ws *wsConnection) enqueue(ctx context.Context, msg models.WSServerMessage) error {
+	select {
+	case ws.send <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reply envia uma resposta de controle sem bloquear a leitura. Se a fila estiver cheia, o cliente não
+// está consumindo as mensagens e a conexão é encerrada.
+func (ws *wsConnection) reply(msg models.WSServerMessage) {
+	select {
+	case ws.send <- msg:
+	default:
+		ws.closeWithReason(wsQueueFullReason)
+	}
+}
+
+// closeWithReason encerra a conexão registrando o motivo enviado ao cliente no frame de fechamento
+func (ws *wsConnection) closeWithReason(reason string) {
+	ws.mu.Lock()
+	if ws.closeReason == "" {
+		ws.closeReason = reason
+	}
+	ws.mu.Unlock()
+	ws.close()
+}
+
+// reason retorna o motivo do encerramento pelo servidor (vazio quando o encerramento é normal)
+func (ws *wsConnection) reason() string {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.closeReason
+}
+
+// register reserva o ID de uma geração, retornando uma mensagem de erro quando não é possível
+func (ws *wsConnection) register(requestID string, cancel context.CancelFunc) string {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if _, exists := ws.inFlight[requestID]; exists {
+		return "Já existe uma geração em andamento com este request_id"
+	}
+	if len(ws.inFlight) >= wsMaxInFlight {
+		return "Limite de gerações simultâneas atingido"
+	}
+	ws.inFlight[requestID] = cancel
+	return ""
+}
+
+func (ws *wsConnection) unregister(requestID string) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	delete(ws.inFlight, requestID)
+}
+
+// cancel interrompe uma geração em andamento, retornando false se ela não existir
+func (ws *wsConnection) cancel(requestID string) bool {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	cancel, ok := ws.inFlight[requestID]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+func (ws *wsConnection) cancelAll() {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	for _, cancel := range ws.inFlight {
+		cancel()
+	}
+}
@@ -0,0 +1,129 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+// TenantCollectionPrefix é o prefixo reservado às coleções próprias dos tenants, que não pode ser
+// usado nas coleções das bases
+const TenantCollectionPrefix = "tenant."
+
+// Base descreve uma base de conhecimento: uma coleção do Qdrant com nome e descrição. A descrição
+// é usada pelo roteador para decidir em quais bases buscar cada pergunta.
+type Base struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Collection é a coleção do Qdrant da base (padrão: o próprio nome)
+	Collection string `json:"collection"`
+	// DocumentsDir é a pasta com os documentos indexados por /index/sample (opcional)
+	DocumentsDir string `json:"documents_dir,omitempty"`
+	// AnswerPolicy é a política de resposta da base (models.AnswerPolicy*); vazio usa a padrão do serviço
+	AnswerPolicy string `json:"answer_policy,omitempty"`
+	// Config são os parâmetros de criação da coleção (padrão: 1536 dimensões, distância Cosine)
+	Config *models.CollectionParams `json:"config,omitempty"`
+}
+
+// Default retorna a base única usada quando nenhum arquivo de bases é configurado
+func Default() []Base {
+	return []Base{
+		{
+			Name:         "default",
+			Description:  "Base de conhecimento geral",
+			Collection:   "rag_documents",
+			DocumentsDir: "./documents",
+		},
+	}
+}
+
+// Load lê as bases de conhecimento de um arquivo JSON no formato {"knowledge_bases": [...]}.
+// A primeira base é a padrão para indexação e listagem quando nenhuma é informada.
+func Load(path string) ([]Base, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo de bases de conhecimento: %w", err)
+	}
+
+	var file struct {
+		KnowledgeBases []Base `json:"knowledge_bases"`
+	}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar arquivo de bases de conhecimento: %w", err)
+	}
+
+	return Validate(file.KnowledgeBases)
+}
+
+// Save grava as bases de conhecimento no arquivo, no mesmo formato lido por Load. O arquivo é
+// escrito em um temporário e renomeado, para que uma falha não deixe a configuração pela metade.
+func Save(path string, bases []Base) error {
+	content, err := json.MarshalIndent(struct {
+		KnowledgeBases []Base `json:"knowledge_bases"`
+	}{bases}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("erro ao serializar bases de conhecimento: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".knowledge_bases-*.json")
+	if err != nil {
+		return fmt.Errorf("erro ao criar arquivo temporário: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(content, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("erro ao gravar bases de conhecimento: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("erro ao gravar bases de conhecimento: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("erro ao substituir arquivo de bases de conhecimento: %w", err)
+	}
+	return nil
+}
+
+// Validate confere nomes, descrições e coleções das bases, preenchendo a coleção padrão
+func Validate(bases []Base) ([]Base, error) {
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("nenhuma base de conhecimento configurada")
+	}
+
+	names := make(map[string]bool)
+	collections := make(map[string]bool)
+	for i := range bases {
+		base := &bases[i]
+		if base.Name == "" {
+			return nil, fmt.Errorf("base de conhecimento %d sem nome", i)
+		}
+		if base.Description == "" {
+			return nil, fmt.Errorf("base de conhecimento '%s' sem descrição", base.Name)
+		}
+		if base.Collection == "" {
+			base.Collection = base.Name
+		}
+		switch base.AnswerPolicy {
+		case "", models.AnswerPolicyGroundedOnly, models.AnswerPolicyGroundedPreferred, models.AnswerPolicyOpen:
+		default:
+			return nil, fmt.Errorf("base de conhecimento '%s' com política de resposta inválida: %s", base.Name, base.AnswerPolicy)
+		}
+		if strings.HasPrefix(base.Collection, TenantCollectionPrefix) {
+			return nil, fmt.Errorf("coleção '%s' usa o prefixo reservado '%s'", base.Collection, TenantCollectionPrefix)
+		}
+		if names[base.Name] {
+			return nil, fmt.Errorf("base de conhecimento '%s' duplicada", base.Name)
+		}
+		if collections[base.Collection] {
+			return nil, fmt.Errorf("coleção '%s' usada por mais de uma base de conhecimento", base.Collection)
+		}
+		names[base.Name] = true
+		collections[base.Collection] = true
+	}
+
+	return bases, nil
+}
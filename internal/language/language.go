@@ -0,0 +1,104 @@
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Idiomas com templates de prompt localizados
+const (
+	PortugueseBR = "pt-BR"
+	English      = "en"
+	Spanish      = "es"
+)
+
+// Supported lista os idiomas suportados, na ordem de preferência em caso de empate
+var Supported = []string{PortugueseBR, English, Spanish}
+
+// names são os nomes dos idiomas usados nas instruções ao modelo
+var names = map[string]string{
+	PortugueseBR: "português brasileiro",
+	English:      "inglês",
+	Spanish:      "espanhol",
+}
+
+// stopwords são palavras funcionais frequentes de cada idioma. Palavras comuns a mais de um
+// idioma ("de", "que", "para") pontuam para todos eles.
+var stopwords = map[string]map[string]struct{}{
+	PortugueseBR: wordSet("o os um uma uns umas do da dos das no na nos nas em com de que para por não é são está estão " +
+		"como qual quais quanto quantos quando onde quem eu você meu minha isso esse essa este esta tem ter posso " +
+		"fazer faço pode ao aos pelo pela mais muito se"),
+	English: wordSet("the a an of to in on and or is are was were be what which who whom how when where why do does " +
+		"did can could should would i you my your it its this that these those with for from about make much many " +
+		"long there have has"),
+	Spanish: wordSet("el la los las un una unos unas del al en con de que para por no es son está están como qué " +
+		"cuál cuáles cuánto cuántos cuándo dónde quién yo tú usted mi eso ese esa este esta tiene tener puedo hacer " +
+		"hago puede más muy se y lo"),
+}
+
+// markers são caracteres que aparecem em apenas um dos idiomas suportados
+var markers = map[rune]string{
+	'ã': PortugueseBR, 'õ': PortugueseBR, 'ç': PortugueseBR, 'â': PortugueseBR, 'ê': PortugueseBR, 'ô': PortugueseBR,
+	'ñ': Spanish, '¿': Spanish, '¡': Spanish,
+}
+
+// IsSupported indica se o idioma tem templates de prompt localizados
+func IsSupported(lang string) bool {
+	_, ok := names[lang]
+	return ok
+}
+
+// Name retorna o nome do idioma para uso em instruções ao modelo
+func Name(lang string) string {
+	if name, ok := names[lang]; ok {
+		return name
+	}
+	return lang
+}
+
+// Detect identifica o idioma do texto pelas palavras funcionais e caracteres típicos de cada idioma.
+// ok é false quando o texto não traz evidência suficiente ou há empate.
+func Detect(text string) (lang string, ok bool) {
+	text = strings.ToLower(text)
+	scores := make(map[string]int)
+
+	for _, r := range text {
+		if marked, found := markers[r]; found {
+			scores[marked] += 2
+		}
+	}
+
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		for candidate, set := range stopwords {
+			if _, found := set[word]; found {
+				scores[candidate]++
+			}
+		}
+	}
+
+	best, bestScore, tied := "", 0, false
+	for _, candidate := range Supported {
+		switch score := scores[candidate]; {
+		case score > bestScore:
+			best, bestScore, tied = candidate, score, false
+		case score == bestScore && score > 0:
+			tied = true
+		}
+	}
+
+	if bestScore == 0 || tied {
+		return "", false
+	}
+	return best, true
+}
+
+func wordSet(words string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, word := range strings.Fields(words) {
+		set[word] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,56 @@
+package language
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		want   string
+		wantOK bool
+	}{
+		{name: "português", text: "Como faço um brigadeiro de panela?", want: PortugueseBR, wantOK: true},
+		{name: "português por acentuação", text: "Informações sobre ações", want: PortugueseBR, wantOK: true},
+		{name: "inglês", text: "How long does it take to make the recipe?", want: English, wantOK: true},
+		{name: "espanhol", text: "¿Cuánto tiempo tarda la receta?", want: Spanish, wantOK: true},
+		{name: "espanhol por ñ", text: "Mañana", want: Spanish, wantOK: true},
+		{name: "maiúsculas são ignoradas", text: "WHAT IS THE RECIPE", want: English, wantOK: true},
+		{name: "texto vazio", text: "", wantOK: false},
+		{name: "sem evidência", text: "RAG 2025 API", wantOK: false},
+		{name: "empate entre idiomas", text: "de que para", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Detect(tt.text)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Detect(%q) = (%q, %v), want (%q, %v)", tt.text, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestIsSupportedAndName(t *testing.T) {
+	tests := []struct {
+		lang          string
+		wantSupported bool
+		wantName      string
+	}{
+		{lang: PortugueseBR, wantSupported: true, wantName: "português brasileiro"},
+		{lang: English, wantSupported: true, wantName: "inglês"},
+		{lang: Spanish, wantSupported: true, wantName: "espanhol"},
+		{lang: "fr", wantSupported: false, wantName: "fr"},
+		{lang: "", wantSupported: false, wantName: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			if got := IsSupported(tt.lang); got != tt.wantSupported {
+				t.Errorf("IsSupported(%q) = %v, want %v", tt.lang, got, tt.wantSupported)
+			}
+			if got := Name(tt.lang); got != tt.wantName {
+				t.Errorf("Name(%q) = %q, want %q", tt.lang, got, tt.wantName)
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
 
 // Document representa um documento no sistema RAG
 type Document struct {
@@ -11,29 +16,424 @@ type Document struct {
 	Created  time.Time         `json:"created"`
 }
 
+// SearchFilter restringe os documentos considerados na busca e nas operações em lote
+type SearchFilter struct {
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	// Source restringe aos documentos da fonte informada
+	Source string `json:"source,omitempty"`
+	// Metadata restringe aos documentos com todos os metadados informados
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Modos de recuperação suportados em QueryRequest.RetrievalMode
+const (
+	// RetrievalModeEmbedding busca pelo embedding da própria pergunta
+	RetrievalModeEmbedding = "embedding"
+	// RetrievalModeHyDE busca pelo embedding de uma resposta hipotética gerada pelo modelo
+	RetrievalModeHyDE = "hyde"
+	// RetrievalModeHyDEHybrid busca pela pergunta e pela resposta hipotética, fundindo os resultados
+	RetrievalModeHyDEHybrid = "hyde_hybrid"
+)
+
+// Modos de roteamento entre bases de conhecimento suportados em QueryRequest.Routing
+const (
+	// RoutingEmbedding escolhe as bases cuja descrição é mais similar à pergunta
+	RoutingEmbedding = "embedding"
+	// RoutingLLM pede ao modelo que escolha as bases pela descrição
+	RoutingLLM = "llm"
+	// RoutingAll busca em todas as bases
+	RoutingAll = "all"
+)
+
+// Políticas de resposta suportadas em QueryRequest.AnswerPolicy
+const (
+	// AnswerPolicyGroundedOnly responde apenas com base no contexto e se abstém quando não há informação
+	AnswerPolicyGroundedOnly = "grounded_only"
+	// AnswerPolicyGroundedPreferred usa o contexto e, sem ele, responde com conhecimento geral avisando o usuário
+	AnswerPolicyGroundedPreferred = "grounded_preferred"
+	// AnswerPolicyOpen usa o contexto quando disponível e conhecimento geral sem avisar
+	AnswerPolicyOpen = "open"
+)
+
+// Caminhos de resposta informados em QueryResponse.AnswerPath
+const (
+	// AnswerPathKnowledgeBase indica resposta fundamentada nos documentos recuperados
+	AnswerPathKnowledgeBase = "knowledge_base"
+	// AnswerPathGeneralKnowledge indica resposta gerada sem contexto da base
+	AnswerPathGeneralKnowledge = "general_knowledge"
+	// AnswerPathAbstained indica que a resposta não foi encontrada na base e o modelo se absteve
+	AnswerPathAbstained = "abstained"
+)
+
 // QueryRequest representa uma requisição de busca
 type QueryRequest struct {
 	Query     string  `json:"query" binding:"required"`
-	TopK      int     `json:"top_k,omitempty"`
-	Threshold float32 `json:"threshold,omitempty"`
+	TopK      int     `json:"top_k,omitempty" binding:"omitempty,min=1,max=100"`
+	Threshold float32 `json:"threshold,omitempty" binding:"omitempty,min=0,max=1"`
+
+	// Reranking em segundo estágio: "lexical" ou "llm" (vazio desativa)
+	Reranker         string `json:"reranker,omitempty" binding:"omitempty,oneof=lexical llm"`
+	RerankCandidates int    `json:"rerank_candidates,omitempty" binding:"omitempty,min=1,max=200"`
+
+	// Diversificação por Maximal Marginal Relevance
+	MMR           bool     `json:"mmr,omitempty"`
+	MMRLambda     *float32 `json:"mmr_lambda,omitempty" binding:"omitempty,min=0,max=1"`
+	MMRCandidates int      `json:"mmr_candidates,omitempty" binding:"omitempty,min=1,max=200"`
+
+	// Expansão da pergunta em paráfrases e subperguntas
+	MultiQuery      bool `json:"multi_query,omitempty"`
+	MultiQueryCount int  `json:"multi_query_count,omitempty" binding:"omitempty,min=1,max=10"`
+
+	// Modo de recuperação: "embedding" (padrão), "hyde" ou "hyde_hybrid"
+	RetrievalMode string `json:"retrieval_mode,omitempty" binding:"omitempty,oneof=embedding hyde hyde_hybrid"`
+
+	// Filtros de payload (created_after, created_before)
+	SearchFilter
+
+	// Bases de conhecimento em que buscar; vazio deixa a escolha para o roteamento
+	Collections []string `json:"collections,omitempty"`
+	// Roteamento entre bases: "embedding", "llm" ou "all" (padrão da configuração quando vazio)
+	Routing string `json:"routing,omitempty" binding:"omitempty,oneof=embedding llm all"`
+
+	// Reforço por recência: com peso 1, um documento novo supera outro até 10% menos relevante
+	// (na faixa de relevância dos candidatos), com decaimento exponencial de meia-vida RecencyHalfLifeDays
+	RecencyWeight       float32 `json:"recency_weight,omitempty" binding:"omitempty,min=0,max=1"`
+	RecencyHalfLifeDays float32 `json:"recency_half_life_days,omitempty" binding:"omitempty,gt=0"`
+
+	// Idioma da resposta: "pt-BR", "en", "es" ou "auto" (padrão), que detecta o idioma da pergunta
+	Language string `json:"language,omitempty" binding:"omitempty,oneof=auto pt-BR en es"`
+
+	// Tradução da pergunta para o idioma dos documentos quando ela está em outro idioma (custa uma
+	// chamada ao modelo). Vazio usa TRANSLATE_QUERIES em consultas e chat; a busca só traduz com true.
+	TranslateQuery *bool `json:"translate_query,omitempty"`
+
+	// Política de resposta: "grounded_only", "grounded_preferred" ou "open" (padrão da coleção quando vazio)
+	AnswerPolicy string `json:"answer_policy,omitempty" binding:"omitempty,oneof=grounded_only grounded_preferred open"`
+
+	// Citações: a resposta referencia as passagens do contexto com [n]
+	Citations bool `json:"citations,omitempty"`
+
+	// Orçamento de tokens para o contexto enviado ao modelo
+	ContextTokenBudget int `json:"context_token_budget,omitempty" binding:"omitempty,min=1"`
+
+	// Template de prompt usado na geração (vazio usa o template padrão)
+	PromptTemplate string `json:"prompt_template,omitempty"`
+
+	// Chave estável do usuário, usada para atribuir a mesma variante de experimento a cada consulta dele
+	UserKey string `json:"user_key,omitempty"`
+
+	// JSON Schema da resposta estruturada: o modelo responde com um objeto JSON validado contra
+	// o schema, devolvido em QueryResponse.Structured. Citações não se aplicam a esse modo.
+	ResponseSchema json.RawMessage `json:"response_schema,omitempty"`
 }
 
 // QueryResponse representa a resposta de uma busca RAG
 type QueryResponse struct {
-	Answer           string             `json:"answer"`
-	RelevantDocs     []RelevantDocument `json:"relevant_docs"`
-	ProcessingTimeMs int64              `json:"processing_time_ms"`
+	// QueryID identifica a consulta para o envio de feedback
+	QueryID              string             `json:"query_id"`
+	Answer               string             `json:"answer"`
+	Language             string             `json:"language"`
+	AnswerPolicy         string             `json:"answer_policy"`
+	AnswerPath           string             `json:"answer_path"`
+	RelevantDocs         []RelevantDocument `json:"relevant_docs"`
+	Collections          []string           `json:"collections"`
+	RetrievalMode        string             `json:"retrieval_mode"`
+	RewrittenQueries     []string           `json:"rewritten_queries,omitempty"`
+	HypotheticalDocument string             `json:"hypothetical_document,omitempty"`
+	TranslatedQuery      string             `json:"translated_query,omitempty"`
+	// FusedQueries é o número de consultas (pergunta, tradução, variações, HyDE) cujos resultados foram
+	// fundidos por RRF; nesse caso o ranking segue fused_score, e não o score vetorial
+	FusedQueries     int            `json:"fused_queries,omitempty"`
+	Context          *ContextReport `json:"context,omitempty"`
+	Citations        []Citation     `json:"citations,omitempty"`
+	InvalidCitations []int          `json:"invalid_citations,omitempty"`
+	// Structured é o objeto extraído da resposta quando a requisição informa response_schema
+	Structured interface{} `json:"structured,omitempty"`
+	// StructuredErrors lista as violações do schema que persistiram após as tentativas de correção
+	StructuredErrors []string           `json:"structured_errors,omitempty"`
+	Usage            *Usage             `json:"usage,omitempty"`
+	PromptTemplate   *PromptTemplateRef `json:"prompt_template,omitempty"`
+	// Experiment identifica a variante de experimento que serviu a consulta
+	Experiment       *ExperimentAssignment `json:"experiment,omitempty"`
+	ProcessingTimeMs int64                 `json:"processing_time_ms"`
+}
+
+// ExperimentAssignment identifica o experimento e a variante atribuídos a uma consulta
+type ExperimentAssignment struct {
+	ExperimentID string `json:"experiment_id"`
+	VariantID    string `json:"variant_id"`
+}
+
+// Avaliações aceitas em FeedbackRequest.Rating
+const (
+	FeedbackPositive = "positive"
+	FeedbackNegative = "negative"
+)
+
+// FeedbackRequest representa a avaliação do usuário sobre uma resposta
+type FeedbackRequest struct {
+	QueryID string `json:"query_id" binding:"required"`
+	Rating  string `json:"rating" binding:"required,oneof=positive negative"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// FeedbackResponse confirma o registro do feedback e informa a variante à qual foi atribuído
+type FeedbackResponse struct {
+	QueryID    string                `json:"query_id"`
+	Experiment *ExperimentAssignment `json:"experiment,omitempty"`
+}
+
+// PromptTemplateRef identifica o template de prompt e a versão usados em uma resposta
+type PromptTemplateRef struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// StreamSessionEvent identifica a sessão de conversa de uma resposta em streaming
+type StreamSessionEvent struct {
+	SessionID       string `json:"session_id"`
+	StandaloneQuery string `json:"standalone_query"`
+}
+
+// StreamDocumentsEvent é o primeiro evento do streaming, com os documentos recuperados
+type StreamDocumentsEvent struct {
+	RelevantDocs         []RelevantDocument `json:"relevant_docs"`
+	Collections          []string           `json:"collections"`
+	Language             string             `json:"language"`
+	RetrievalMode        string             `json:"retrieval_mode"`
+	RewrittenQueries     []string           `json:"rewritten_queries,omitempty"`
+	HypotheticalDocument string             `json:"hypothetical_document,omitempty"`
+	TranslatedQuery      string             `json:"translated_query,omitempty"`
+	// FusedQueries é o número de consultas (pergunta, tradução, variações, HyDE) cujos resultados foram
+	// fundidos por RRF; nesse caso o ranking segue fused_score, e não o score vetorial
+	FusedQueries int            `json:"fused_queries,omitempty"`
+	Context      *ContextReport `json:"context,omitempty"`
+}
+
+// StreamTokenEvent traz um trecho da resposta em streaming
+type StreamTokenEvent struct {
+	Content string `json:"content"`
+}
+
+// StreamDoneEvent encerra o streaming com o caminho da resposta, citações, consumo e tempos
+type StreamDoneEvent struct {
+	AnswerPolicy     string                `json:"answer_policy"`
+	AnswerPath       string                `json:"answer_path"`
+	Citations        []Citation            `json:"citations,omitempty"`
+	InvalidCitations []int                 `json:"invalid_citations,omitempty"`
+	Usage            *Usage                `json:"usage,omitempty"`
+	PromptTemplate   *PromptTemplateRef    `json:"prompt_template,omitempty"`
+	QueryID          string                `json:"query_id"`
+	Experiment       *ExperimentAssignment `json:"experiment,omitempty"`
+	RetrievalTimeMs  int64                 `json:"retrieval_time_ms"`
+	GenerationTimeMs int64                 `json:"generation_time_ms"`
+	ProcessingTimeMs int64                 `json:"processing_time_ms"`
+}
+
+// Usage representa o consumo de tokens da geração da resposta
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+	// Estimated indica contagem feita localmente com o tokenizer (respostas em streaming)
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// Citation associa um trecho da resposta a um documento do contexto
+type Citation struct {
+	// Passage é o número [n] citado na resposta
+	Passage    int    `json:"passage"`
+	DocumentID string `json:"document_id"`
+	Source     string `json:"source"`
+	// Start e End delimitam, em caracteres, o trecho da resposta sustentado pela citação
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+}
+
+// ContextReport descreve quais documentos entraram no contexto enviado ao modelo
+type ContextReport struct {
+	TokenBudget int            `json:"token_budget"`
+	UsedTokens  int            `json:"used_tokens"`
+	Included    []ContextEntry `json:"included"`
+	Dropped     []ContextEntry `json:"dropped"`
+}
+
+// ContextEntry representa um documento considerado na montagem do contexto
+type ContextEntry struct {
+	DocumentID string `json:"document_id"`
+	Source     string `json:"source"`
+	Tokens     int    `json:"tokens"`
+	Truncated  bool   `json:"truncated,omitempty"`
+	// Reason explica o descarte: "below_score" ou "budget"
+	Reason string `json:"reason,omitempty"`
+}
+
+// SearchRequest representa uma busca sem geração de resposta, com paginação por offset
+type SearchRequest struct {
+	QueryRequest
+	Offset int `json:"offset,omitempty" binding:"omitempty,min=0,max=1000"`
+}
+
+// SearchResponse representa a resposta de uma busca sem geração de resposta
+type SearchResponse struct {
+	Results              []RelevantDocument `json:"results"`
+	Count                int                `json:"count"`
+	Offset               int                `json:"offset"`
+	NextOffset           *int               `json:"next_offset,omitempty"`
+	Collections          []string           `json:"collections"`
+	RetrievalMode        string             `json:"retrieval_mode"`
+	RewrittenQueries     []string           `json:"rewritten_queries,omitempty"`
+	HypotheticalDocument string             `json:"hypothetical_document,omitempty"`
+	TranslatedQuery      string             `json:"translated_query,omitempty"`
+	// FusedQueries é o número de consultas (pergunta, tradução, variações, HyDE) cujos resultados foram
+	// fundidos por RRF; nesse caso o ranking segue fused_score, e não o score vetorial
+	FusedQueries     int   `json:"fused_queries,omitempty"`
+	ProcessingTimeMs int64 `json:"processing_time_ms"`
 }
 
 // RelevantDocument representa um documento relevante encontrado
 type RelevantDocument struct {
 	Document Document `json:"document"`
-	Score    float32  `json:"score"`
+	// Collection é a base de conhecimento de onde o documento foi recuperado
+	Collection string `json:"collection,omitempty"`
+	// Score é o score de similaridade da busca vetorial
+	Score float32 `json:"score"`
+	// RerankScore é o score atribuído pelo reranker, quando utilizado
+	RerankScore *float32 `json:"rerank_score,omitempty"`
+	// FusedScore é o score combinado (RRF) quando a busca usa várias consultas
+	FusedScore *float32 `json:"fused_score,omitempty"`
+	// RecencyBoost é o reforço por recência somado à relevância normalizada no score de ranking, quando solicitado
+	RecencyBoost *float32 `json:"recency_boost,omitempty"`
+	// Highlights são os trechos do documento, escapados para HTML, com os termos da query entre <em>
+	Highlights []string `json:"highlights,omitempty"`
+	// Vector é o embedding do documento, preenchido apenas quando solicitado na busca
+	Vector []float32 `json:"-"`
 }
 
 // IndexRequest representa uma requisição para indexar documentos
 type IndexRequest struct {
 	Documents []Document `json:"documents" binding:"required"`
+	// Collection é a base de conhecimento de destino (vazio para a base padrão)
+	Collection string `json:"collection,omitempty"`
+}
+
+// UpdateDocumentRequest altera um documento indexado; apenas os campos informados mudam. Alterar o
+// conteúdo gera um novo embedding; as demais alterações só atualizam o payload.
+type UpdateDocumentRequest struct {
+	Content *string `json:"content,omitempty" binding:"omitempty,min=1"`
+	Source  *string `json:"source,omitempty"`
+	// Metadata grava as chaves informadas, mantendo as demais
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// RemoveMetadata remove as chaves informadas dos metadados
+	RemoveMetadata []string `json:"remove_metadata,omitempty"`
+}
+
+// UpdateDocumentResponse representa o documento depois da alteração
+type UpdateDocumentResponse struct {
+	Document Document `json:"document"`
+	// Reembedded indica que o conteúdo mudou e o embedding foi gerado novamente
+	Reembedded bool `json:"reembedded"`
+}
+
+// DeleteByFilterRequest remove todos os documentos que atendem ao filtro
+type DeleteByFilterRequest struct {
+	Filter SearchFilter `json:"filter"`
+	// Collection é a base de conhecimento alvo (vazio para a base padrão)
+	Collection string `json:"collection,omitempty"`
+	// DryRun apenas conta os documentos afetados, sem alterar nada
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// UpdateByFilterRequest altera a fonte e os metadados de todos os documentos que atendem ao filtro,
+// sem gerar embeddings
+type UpdateByFilterRequest struct {
+	Filter     SearchFilter `json:"filter"`
+	Collection string       `json:"collection,omitempty"`
+	DryRun     bool         `json:"dry_run,omitempty"`
+
+	Source         *string           `json:"source,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	RemoveMetadata []string          `json:"remove_metadata,omitempty"`
+}
+
+// BulkOperationResponse informa quantos documentos a operação em lote afetou (ou afetaria, em dry run)
+type BulkOperationResponse struct {
+	Matched int  `json:"matched"`
+	DryRun  bool `json:"dry_run"`
+}
+
+// PointID é o ID de um ponto no Qdrant: um UUID ou um inteiro sem sinal. No JSON, IDs numéricos são
+// lidos e escritos como números.
+type PointID string
+
+// IsNumeric indica se o ID é um inteiro sem sinal
+func (id PointID) IsNumeric() bool {
+	_, err := strconv.ParseUint(string(id), 10, 64)
+	return err == nil
+}
+
+// MarshalJSON escreve IDs numéricos como número e os demais como string
+func (id PointID) MarshalJSON() ([]byte, error) {
+	if id.IsNumeric() {
+		return []byte(id), nil
+	}
+	return json.Marshal(string(id))
+}
+
+// UnmarshalJSON aceita o ID como string ou como número inteiro
+func (id *PointID) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*id = PointID(text)
+		return nil
+	}
+
+	var number uint64
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("ID deve ser um UUID ou um inteiro sem sinal: %s", data)
+	}
+	*id = PointID(strconv.FormatUint(number, 10))
+	return nil
+}
+
+// PointRecord é um ponto da coleção no formato portátil de exportação e importação: uma linha JSONL
+// com ID, payload e, opcionalmente, o vetor
+type PointRecord struct {
+	ID      PointID                `json:"id"`
+	Payload map[string]interface{} `json:"payload"`
+	Vector  []float32              `json:"vector,omitempty"`
+}
+
+// CollectionHeader é a primeira linha do arquivo de exportação, com a descrição da base e os
+// parâmetros dos vetores, usados para criar a coleção na importação
+type CollectionHeader struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	VectorSize  int    `json:"vector_size"`
+	Distance    string `json:"distance"`
+}
+
+// ImportOptions controla a importação de uma coleção
+type ImportOptions struct {
+	// CreateIfMissing cria a base quando ela não existe, com a dimensão e a distância do arquivo
+	CreateIfMissing bool
+	// Distance substitui a distância do cabeçalho do arquivo na criação da coleção
+	Distance string
+}
+
+// ImportResponse representa o resultado da importação de uma coleção
+type ImportResponse struct {
+	// Created indica que a base não existia e foi criada pela importação
+	Created  bool `json:"created,omitempty"`
+	Imported int  `json:"imported"`
+	// Embedded conta os registros sem vetor, cujo embedding foi gerado a partir de payload.content
+	Embedded int `json:"embedded"`
+	Failed   int `json:"failed"`
+	// Errors descreve os registros recusados, pelo número da linha (limitado às primeiras falhas)
+	Errors         []string `json:"errors,omitempty"`
+	ProcessingTime string   `json:"processing_time"`
 }
 
 // IndexResponse representa a resposta da indexação
@@ -43,3 +443,190 @@ type IndexResponse struct {
 	FailedDocs     []string `json:"failed_docs,omitempty"`
 	ProcessingTime string   `json:"processing_time"`
 }
+
+// Papéis das mensagens de uma conversa
+const (
+	ChatRoleUser      = "user"
+	ChatRoleAssistant = "assistant"
+)
+
+// ChatMessage representa uma mensagem de uma sessão de conversa
+type ChatMessage struct {
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ChatSession representa uma sessão de conversa com seu histórico
+type ChatSession struct {
+	ID         string        `json:"id"`
+	Messages   []ChatMessage `json:"messages"`
+	TTLSeconds int           `json:"ttl_seconds"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+}
+
+// ChatSessionSummary resume uma sessão na listagem
+type ChatSessionSummary struct {
+	ID           string    `json:"id"`
+	MessageCount int       `json:"message_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// ChatRequest representa uma mensagem enviada em uma conversa. O campo query é a mensagem do
+// usuário; os demais parâmetros de QueryRequest se aplicam à recuperação e geração.
+type ChatRequest struct {
+	QueryRequest
+	// SessionID identifica a conversa; vazio cria uma nova sessão
+	SessionID string `json:"session_id,omitempty"`
+	// TTLSeconds define a expiração por inatividade de uma nova sessão
+	TTLSeconds int `json:"ttl_seconds,omitempty" binding:"omitempty,min=1"`
+}
+
+// ChatResponse representa a resposta a uma mensagem da conversa
+type ChatResponse struct {
+	SessionID string `json:"session_id"`
+	// StandaloneQuery é a pergunta reescrita com o contexto da conversa, usada na recuperação
+	StandaloneQuery string `json:"standalone_query"`
+	QueryResponse
+}
+
+// Tipos de mensagem do protocolo WebSocket de chat. Além destes, o servidor repassa os eventos
+// de streaming ("session", "documents", "token", "done") como tipos de mensagem.
+const (
+	WSTypeMessage   = "message"
+	WSTypeCancel    = "cancel"
+	WSTypePing      = "ping"
+	WSTypePong      = "pong"
+	WSTypeCancelled = "cancelled"
+	WSTypeError     = "error"
+)
+
+// WSClientMessage representa uma mensagem enviada pelo cliente no WebSocket de chat
+type WSClientMessage struct {
+	// Type é "message", "cancel" ou "ping"
+	Type string `json:"type"`
+	// RequestID correlaciona a mensagem com os eventos de resposta e com o cancelamento
+	RequestID string `json:"request_id,omitempty"`
+	ChatRequest
+}
+
+// WSServerMessage representa uma mensagem enviada pelo servidor no WebSocket de chat
+type WSServerMessage struct {
+	Type      string      `json:"type"`
+	RequestID string      `json:"request_id,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// AgentRequest representa uma pergunta respondida no modo agente, em que o modelo decide quais
+// ferramentas de busca chamar e em que ordem
+type AgentRequest struct {
+	Query string `json:"query" binding:"required"`
+	// MaxSteps limita os passos de chamadas de ferramentas antes de forçar a resposta (padrão 5)
+	MaxSteps int    `json:"max_steps,omitempty" binding:"omitempty,min=1,max=10"`
+	Language string `json:"language,omitempty" binding:"omitempty,oneof=auto pt-BR en es"`
+}
+
+// AgentStep registra uma chamada de ferramenta feita pelo agente
+type AgentStep struct {
+	Step      int             `json:"step"`
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments"`
+	// Summary resume o resultado devolvido ao modelo
+	Summary    string `json:"summary,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// AgentResponse representa a resposta do modo agente com o rastro das ferramentas chamadas
+type AgentResponse struct {
+	Answer           string      `json:"answer"`
+	Language         string      `json:"language"`
+	Steps            []AgentStep `json:"steps"`
+	StepLimitReached bool        `json:"step_limit_reached"`
+	Usage            *Usage      `json:"usage,omitempty"`
+	ProcessingTimeMs int64       `json:"processing_time_ms"`
+}
+
+// Tipos de quantização aceitos em QuantizationConfig.Type
+const (
+	QuantizationScalar  = "scalar"
+	QuantizationBinary  = "binary"
+	QuantizationProduct = "product"
+	// QuantizationDisabled remove a quantização de uma coleção existente
+	QuantizationDisabled = "disabled"
+)
+
+// HNSWConfig ajusta o índice HNSW de uma coleção; campos vazios mantêm o padrão do Qdrant
+type HNSWConfig struct {
+	M                 *int  `json:"m,omitempty" binding:"omitempty,min=0"`
+	EfConstruct       *int  `json:"ef_construct,omitempty" binding:"omitempty,min=4"`
+	FullScanThreshold *int  `json:"full_scan_threshold,omitempty" binding:"omitempty,min=0"`
+	OnDisk            *bool `json:"on_disk,omitempty"`
+}
+
+// QuantizationConfig configura a quantização dos vetores de uma coleção
+type QuantizationConfig struct {
+	Type string `json:"type" binding:"required,oneof=scalar binary product disabled"`
+	// Quantile é o quantil usado na quantização escalar (padrão do Qdrant quando vazio)
+	Quantile *float32 `json:"quantile,omitempty" binding:"omitempty,gte=0.5,lte=1"`
+	// Compression é a taxa de compressão da quantização por produto
+	Compression string `json:"compression,omitempty" binding:"omitempty,oneof=x4 x8 x16 x32 x64"`
+	AlwaysRAM   *bool  `json:"always_ram,omitempty"`
+}
+
+// CollectionParams são os parâmetros de criação de uma coleção
+type CollectionParams struct {
+	// VectorSize deve corresponder à dimensão do modelo de embedding (padrão 1536)
+	VectorSize int `json:"vector_size,omitempty" binding:"omitempty,min=1,max=65536"`
+	// Distance é a métrica de distância: Cosine (padrão), Dot, Euclid ou Manhattan
+	Distance      string              `json:"distance,omitempty" binding:"omitempty,oneof=Cosine Dot Euclid Manhattan"`
+	OnDisk        bool                `json:"on_disk,omitempty"`
+	OnDiskPayload bool                `json:"on_disk_payload,omitempty"`
+	HNSW          *HNSWConfig         `json:"hnsw,omitempty"`
+	Quantization  *QuantizationConfig `json:"quantization,omitempty"`
+}
+
+// CreateCollectionRequest cria uma base de conhecimento com uma coleção nova no Qdrant
+type CreateCollectionRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description" binding:"required"`
+	// Collection é o nome da coleção no Qdrant (padrão: o nome da base)
+	Collection string `json:"collection,omitempty"`
+	// AnswerPolicy é a política de resposta da base (vazio usa a padrão do serviço)
+	AnswerPolicy string `json:"answer_policy,omitempty" binding:"omitempty,oneof=grounded_only grounded_preferred open"`
+	CollectionParams
+}
+
+// UpdateCollectionRequest altera a descrição e os parâmetros ajustáveis de uma coleção; dimensão e
+// distância dos vetores não podem ser alteradas depois da criação
+type UpdateCollectionRequest struct {
+	Description   *string             `json:"description,omitempty" binding:"omitempty,min=1"`
+	AnswerPolicy  *string             `json:"answer_policy,omitempty" binding:"omitempty,oneof=grounded_only grounded_preferred open"`
+	OnDisk        *bool               `json:"on_disk,omitempty"`
+	OnDiskPayload *bool               `json:"on_disk_payload,omitempty"`
+	HNSW          *HNSWConfig         `json:"hnsw,omitempty"`
+	Quantization  *QuantizationConfig `json:"quantization,omitempty"`
+}
+
+// CollectionDescription descreve uma base de conhecimento e o estado da sua coleção
+type CollectionDescription struct {
+	Name                string      `json:"name"`
+	Description         string      `json:"description"`
+	Collection          string      `json:"collection"`
+	AnswerPolicy        string      `json:"answer_policy"`
+	Status              string      `json:"status"`
+	PointsCount         int         `json:"points_count"`
+	IndexedVectorsCount int         `json:"indexed_vectors_count"`
+	SegmentsCount       int         `json:"segments_count"`
+	VectorSize          int         `json:"vector_size"`
+	Distance            string      `json:"distance"`
+	OnDisk              bool        `json:"on_disk"`
+	OnDiskPayload       bool        `json:"on_disk_payload"`
+	HNSW                interface{} `json:"hnsw,omitempty"`
+	Quantization        interface{} `json:"quantization,omitempty"`
+}
@@ -0,0 +1,138 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/language"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/sashabaranov/go-openai"
+)
+
+// AgentTool descreve uma ferramenta que o modelo pode chamar no modo agente
+type AgentTool struct {
+	Name        string
+	Description string
+	// Parameters é o JSON Schema dos argumentos da ferramenta
+	Parameters json.RawMessage
+}
+
+// ToolCall é uma chamada de ferramenta solicitada pelo modelo
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// ToolExecutor executa uma chamada de ferramenta no passo informado e retorna o resultado enviado
+// ao modelo. Erros também são repassados ao modelo, para que ele possa corrigir a chamada.
+type ToolExecutor func(ctx context.Context, step int, call ToolCall) (string, error)
+
+// AgentResult é o resultado de uma execução do agente
+type AgentResult struct {
+	Answer string
+	// Steps é o número de passos em que o modelo chamou ferramentas
+	Steps int
+	// StepLimitReached indica que o limite de passos foi atingido e a resposta foi forçada
+	StepLimitReached bool
+	Usage            models.Usage
+}
+
+// RunAgent conduz o ciclo de chamadas de ferramentas: a cada passo o modelo pode chamar ferramentas,
+// cujos resultados voltam para ele, até responder sem chamá-las. Atingido o limite de passos, o modelo
+// é obrigado a responder com o que já obteve.
+func (c *Client) RunAgent(ctx context.Context, query string, lang string, tools []AgentTool, maxSteps int, execute ToolExecutor) (*AgentResult, error) {
+	c.logger.Debugf("Executando agente com até %d passos para query: %s", maxSteps, query)
+
+	systemPrompt := fmt.Sprintf(`Você é um assistente que pesquisa uma base de conhecimento para responder perguntas.
+
+INSTRUÇÕES:
+1. Use as ferramentas para buscar informações antes de responder; perguntas complexas podem exigir várias buscas
+2. Refine as buscas com base nos resultados anteriores (outros termos, fontes específicas, documentos completos)
+3. Quando tiver informações suficientes, responda sem chamar mais ferramentas
+4. Baseie a resposta nos resultados das ferramentas e diga quando a base não tiver a informação
+5. Responda em %s
+6. Seja preciso, útil e completo`, language.Name(lang))
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: systemPrompt,
+		},
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: query,
+		},
+	}
+
+	toolDefinitions := make([]openai.Tool, 0, len(tools))
+	for _, tool := range tools {
+		toolDefinitions = append(toolDefinitions, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+
+	result := &AgentResult{}
+	for {
+		req := openai.ChatCompletionRequest{
+			Model:       c.model,
+			Messages:    messages,
+			Tools:       toolDefinitions,
+			ToolChoice:  "auto",
+			MaxTokens:   1000,
+			Temperature: 0.1,
+		}
+		if result.Steps >= maxSteps {
+			// Limite atingido: a última chamada não pode usar ferramentas
+			req.ToolChoice = "none"
+			result.StepLimitReached = true
+		}
+
+		resp, err := c.client.CreateChatCompletion(ctx, req)
+		if err != nil {
+			c.logger.WithError(err).Error("Erro ao executar passo do agente")
+			return nil, fmt.Errorf("erro ao executar passo do agente: %w", err)
+		}
+		if len(resp.Choices) == 0 {
+			return nil, fmt.Errorf("nenhuma resposta gerada pelo agente")
+		}
+
+		result.Usage.PromptTokens += resp.Usage.PromptTokens
+		result.Usage.CompletionTokens += resp.Usage.CompletionTokens
+		result.Usage.TotalTokens += resp.Usage.TotalTokens
+
+		message := resp.Choices[0].Message
+		if len(message.ToolCalls) == 0 || result.StepLimitReached {
+			result.Answer = message.Content
+			return result, nil
+		}
+
+		result.Steps++
+		messages = append(messages, message)
+		for _, call := range message.ToolCalls {
+			output, err := execute(ctx, result.Steps, ToolCall{
+				ID:        call.ID,
+				Name:      call.Function.Name,
+				Arguments: call.Function.Arguments,
+			})
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if err != nil {
+				output = fmt.Sprintf("ERRO: %v", err)
+			}
+
+			messages = append(messages, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				Content:    output,
+				ToolCallID: call.ID,
+			})
+		}
+	}
+}
@@ -2,14 +2,26 @@ package openai
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"strings"
 
+	"github.com/marcopollivier/rag-go-ex01/internal/language"
 	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/prompts"
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
 	"github.com/sashabaranov/go-openai"
 	"github.com/sirupsen/logrus"
 )
 
+// zeroTemperature substitui a temperatura zero nas requisições: a biblioteca omite temperatura zero
+// do JSON, o que aplicaria o padrão da API (1)
+const zeroTemperature = math.SmallestNonzeroFloat32
+
 type Client struct {
 	client *openai.Client
 	model  string
@@ -18,6 +30,8 @@ type Client struct {
 
 // NewClient cria um novo cliente OpenAI
 func NewClient(apiKey string, logger *logrus.Logger) *Client {
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+
 	return &Client{
 		client: openai.NewClient(apiKey),
 		model:  openai.GPT3Dot5Turbo,
@@ -25,12 +39,27 @@ func NewClient(apiKey string, logger *logrus.Logger) *Client {
 	}
 }
 
+// Model retorna o modelo de chat utilizado
+func (c *Client) Model() string {
+	return c.model
+}
+
 // GenerateEmbedding gera embedding para um texto
 func (c *Client) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
 	c.logger.Debugf("Gerando embedding para texto de %d caracteres", len(text))
 
+	embeddings, err := c.GenerateEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+
+	return embeddings[0], nil
+}
+
+// GenerateEmbeddings gera embeddings para vários textos em uma única chamada
+func (c *Client) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
 	req := openai.EmbeddingRequest{
-		Input: []string{text},
+		Input: texts,
 		Model: openai.AdaEmbeddingV2,
 	}
 
@@ -40,53 +69,469 @@ func (c *Client) GenerateEmbedding(ctx context.Context, text string) ([]float32,
 		return nil, fmt.Errorf("erro ao gerar embedding: %w", err)
 	}
 
-	if len(resp.Data) == 0 {
-		return nil, fmt.Errorf("nenhum embedding retornado")
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("esperados %d embeddings, retornados %d", len(texts), len(resp.Data))
 	}
 
-	return resp.Data[0].Embedding, nil
+	embeddings := make([][]float32, len(texts))
+	for _, data := range resp.Data {
+		embeddings[data.Index] = data.Embedding
+	}
+
+	return embeddings, nil
+}
+
+// InsufficientContextAnswer é a resposta exata pedida ao modelo quando, no modo estritamente
+// fundamentado, o contexto não é suficiente para responder
+const InsufficientContextAnswer = "NAO_ENCONTRADO"
+
+// AnswerOptions ajusta a geração de respostas
+type AnswerOptions struct {
+	// Template é o template de prompt usado para montar as mensagens
+	Template *prompts.Template
+	// Citations numera os documentos do contexto e pede referências [n] após cada afirmação
+	Citations bool
+	// Policy define o uso de conhecimento geral (models.AnswerPolicy*); vazio equivale a "open"
+	Policy string
+	// History são as mensagens anteriores da conversa, enviadas antes da pergunta atual
+	History []models.ChatMessage
+	// Language é o idioma da resposta (language.*), que escolhe o arquivo do template
+	Language string
+	// CrossLingual avisa o modelo de que o contexto pode estar em outro idioma
+	CrossLingual bool
+	// ResponseSchema é um JSON Schema; quando informado, o modelo deve responder com um objeto JSON que o satisfaça
+	ResponseSchema string
+	// Model substitui o modelo de chat padrão do cliente
+	Model string
+	// Temperature substitui a temperatura padrão da geração (0.1)
+	Temperature *float32
+	// Repair traz uma resposta anterior que violou o schema, para que o modelo a corrija
+	Repair *SchemaRepair
+}
+
+// SchemaRepair descreve uma resposta estruturada inválida e as violações encontradas
+type SchemaRepair struct {
+	Answer     string
+	Violations []string
 }
 
-// GenerateAnswer gera uma resposta baseada no contexto e pergunta
-func (c *Client) GenerateAnswer(ctx context.Context, query string, docs []models.RelevantDocument) (string, error) {
+// GenerateAnswer gera uma resposta baseada no contexto e pergunta. Todos os documentos recebidos
+// entram no contexto; a seleção por relevância e orçamento de tokens é feita pelo chamador.
+func (c *Client) GenerateAnswer(ctx context.Context, query string, docs []models.RelevantDocument, opts AnswerOptions) (string, models.Usage, error) {
 	c.logger.Debugf("Gerando resposta para query: %s com %d documentos", query, len(docs))
 
-	// Construir contexto a partir dos documentos relevantes
-	var contextParts []string
-	hasRelevantDocs := false
+	req, err := c.answerRequest(query, docs, opts)
+	if err != nil {
+		return "", models.Usage{}, err
+	}
+
+	resp, err := c.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		c.logger.WithError(err).Error("Erro ao gerar resposta")
+		return "", models.Usage{}, fmt.Errorf("erro ao gerar resposta: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", models.Usage{}, fmt.Errorf("nenhuma resposta gerada")
+	}
+
+	answer := resp.Choices[0].Message.Content
+	c.logger.Debugf("Resposta gerada com %d caracteres", len(answer))
+
+	usage := models.Usage{
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		TotalTokens:      resp.Usage.TotalTokens,
+	}
+	return answer, usage, nil
+}
+
+// GenerateAnswerStream gera a resposta em streaming, chamando onDelta a cada trecho recebido.
+// A API de streaming não informa consumo de tokens, então o uso retornado é estimado com o tokenizer do modelo.
+func (c *Client) GenerateAnswerStream(ctx context.Context, query string, docs []models.RelevantDocument, opts AnswerOptions, onDelta func(string) error) (string, models.Usage, error) {
+	c.logger.Debugf("Gerando resposta em streaming para query: %s com %d documentos", query, len(docs))
 
+	req, err := c.answerRequest(query, docs, opts)
+	if err != nil {
+		return "", models.Usage{}, err
+	}
+	req.Stream = true
+
+	stream, err := c.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		c.logger.WithError(err).Error("Erro ao iniciar streaming da resposta")
+		return "", models.Usage{}, fmt.Errorf("erro ao gerar resposta: %w", err)
+	}
+	defer stream.Close()
+
+	var answer strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			c.logger.WithError(err).Error("Erro ao receber trecho da resposta")
+			return "", models.Usage{}, fmt.Errorf("erro ao receber resposta: %w", err)
+		}
+
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta.Content
+		answer.WriteString(delta)
+		if err := onDelta(delta); err != nil {
+			return "", models.Usage{}, err
+		}
+	}
+
+	c.logger.Debugf("Resposta em streaming gerada com %d caracteres", answer.Len())
+	return answer.String(), c.estimateUsage(req.Model, req.Messages, answer.String()), nil
+}
+
+// answerRequest renderiza o template de prompt e monta a requisição de chat com o contexto,
+// o histórico e a pergunta
+func (c *Client) answerRequest(query string, docs []models.RelevantDocument, opts AnswerOptions) (openai.ChatCompletionRequest, error) {
+	if opts.Template == nil {
+		return openai.ChatCompletionRequest{}, fmt.Errorf("template de prompt não informado")
+	}
+
+	data := prompts.Data{
+		Query:                     query,
+		HasContext:                len(docs) > 0,
+		Citations:                 opts.Citations,
+		Policy:                    opts.Policy,
+		InsufficientContextAnswer: InsufficientContextAnswer,
+		Language:                  opts.Language,
+		CrossLingual:              opts.CrossLingual,
+		ResponseSchema:            opts.ResponseSchema,
+	}
 	for i, doc := range docs {
-		if doc.Score >= 0.75 {
-			hasRelevantDocs = true
-			contextParts = append(contextParts, fmt.Sprintf("Documento %d (Score: %.2f):\n%s",
-				i+1, doc.Score, doc.Document.Content))
+		data.Documents = append(data.Documents, prompts.Document{
+			Index:   i + 1,
+			Source:  doc.Document.Source,
+			Score:   doc.Score,
+			Content: doc.Document.Content,
+		})
+	}
+
+	systemPrompt, userPrompt, err := opts.Template.Render(opts.Language, data)
+	if err != nil {
+		return openai.ChatCompletionRequest{}, err
+	}
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: systemPrompt,
+		},
+	}
+	for _, message := range opts.History {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    message.Role,
+			Content: message.Content,
+		})
+	}
+	messages = append(messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: userPrompt,
+	})
+	if opts.Repair != nil {
+		repairPrompt, err := opts.Template.RenderRepair(opts.Language, prompts.RepairData{Violations: opts.Repair.Violations})
+		if err != nil {
+			return openai.ChatCompletionRequest{}, err
+		}
+		messages = append(messages,
+			openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleAssistant,
+				Content: opts.Repair.Answer,
+			},
+			openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleUser,
+				Content: repairPrompt,
+			})
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model:       c.model,
+		Messages:    messages,
+		MaxTokens:   1000,
+		Temperature: 0.1,
+	}
+	if opts.Model != "" {
+		req.Model = opts.Model
+	}
+	if opts.Temperature != nil {
+		req.Temperature = *opts.Temperature
+		if req.Temperature == 0 {
+			req.Temperature = zeroTemperature
 		}
 	}
+	return req, nil
+}
+
+// estimateUsage estima o consumo de tokens de uma chamada de chat com o tokenizer do modelo
+func (c *Client) estimateUsage(model string, messages []openai.ChatCompletionMessage, answer string) models.Usage {
+	encoding, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		c.logger.WithError(err).Warn("Tokenizer indisponível; uso de tokens não estimado")
+		return models.Usage{Estimated: true}
+	}
+
+	// Cada mensagem tem um overhead fixo de formatação de ~4 tokens, mais 3 de preparação da resposta
+	promptTokens := 3
+	for _, message := range messages {
+		promptTokens += 4 + len(encoding.EncodeOrdinary(message.Content))
+	}
+	completionTokens := len(encoding.EncodeOrdinary(answer))
+
+	return models.Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+		Estimated:        true,
+	}
+}
+
+const (
+	// maxRerankPassageChars limita o tamanho de cada trecho enviado para pontuação
+	maxRerankPassageChars = 1500
+	// rerankBatchSize é o número de trechos pontuados por chamada, o que limita o prompt a
+	// rerankBatchSize*maxRerankPassageChars caracteres de trechos
+	rerankBatchSize = 10
+	// rerankTokensPerScore reserva tokens de resposta para cada nota e seu separador no array
+	rerankTokensPerScore = 4
+)
+
+// ScoreRelevance pede ao modelo uma nota de relevância (0 a 1) para cada trecho em relação à pergunta,
+// pontuando os trechos em lotes de rerankBatchSize
+func (c *Client) ScoreRelevance(ctx context.Context, query string, passages []string) ([]float32, error) {
+	c.logger.Debugf("Pontuando %d trechos para query: %s", len(passages), query)
 
-	var userPrompt string
-	if hasRelevantDocs {
-		context := strings.Join(contextParts, "\n\n")
-		userPrompt = fmt.Sprintf(`CONTEXTO RELEVANTE:
+	scores := make([]float32, 0, len(passages))
+	for start := 0; start < len(passages); start += rerankBatchSize {
+		end := start + rerankBatchSize
+		if end > len(passages) {
+			end = len(passages)
+		}
+
+		batchScores, err := c.scoreRelevanceBatch(ctx, query, passages[start:end])
+		if err != nil {
+			return nil, err
+		}
+		scores = append(scores, batchScores...)
+	}
+
+	return scores, nil
+}
+
+// scoreRelevanceBatch pontua um lote de trechos em uma única chamada ao modelo
+func (c *Client) scoreRelevanceBatch(ctx context.Context, query string, passages []string) ([]float32, error) {
+	var passageParts []string
+	for i, passage := range passages {
+		if runes := []rune(passage); len(runes) > maxRerankPassageChars {
+			passage = string(runes[:maxRerankPassageChars])
+		}
+		passageParts = append(passageParts, fmt.Sprintf("[%d]\n%s", i+1, passage))
+	}
+
+	systemPrompt := `Você avalia a relevância de trechos de documentos para uma pergunta.
+
+INSTRUÇÕES:
+1. Atribua a cada trecho uma nota inteira de 0 (irrelevante) a 10 (responde diretamente à pergunta)
+2. Responda SOMENTE com um array JSON de notas, na mesma ordem dos trechos
+3. O array deve ter exatamente um elemento por trecho, por exemplo: [7, 0, 10]`
+
+	userPrompt := fmt.Sprintf(`PERGUNTA: %s
+
+TRECHOS:
 %s
 
-PERGUNTA: %s
+NOTAS:`, query, strings.Join(passageParts, "\n\n"))
 
-RESPOSTA (baseada no contexto):`, context, query)
-	} else {
-		userPrompt = fmt.Sprintf(`PERGUNTA: %s
+	req := openai.ChatCompletionRequest{
+		Model: c.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: systemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: userPrompt,
+			},
+		},
+		MaxTokens:   16 + rerankTokensPerScore*len(passages),
+		Temperature: zeroTemperature,
+	}
+
+	resp, err := c.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		c.logger.WithError(err).Error("Erro ao pontuar trechos")
+		return nil, fmt.Errorf("erro ao pontuar trechos: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("nenhuma pontuação gerada")
+	}
+
+	var rawScores []float32
+	if err := decodeJSONArray(resp.Choices[0].Message.Content, &rawScores); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar pontuação: %w", err)
+	}
 
-Responda de forma direta e objetiva, sem mencionar fontes ou falta de informações:`, query)
+	if len(rawScores) != len(passages) {
+		return nil, fmt.Errorf("esperadas %d notas, recebidas %d", len(passages), len(rawScores))
 	}
 
-	systemPrompt := `Você é um assistente inteligente que responde perguntas de forma útil e objetiva.
+	scores := make([]float32, len(rawScores))
+	for i, score := range rawScores {
+		scores[i] = score / 10
+	}
+
+	return scores, nil
+}
+
+// RewriteQuery reescreve a pergunta em paráfrases e subperguntas para ampliar a recuperação
+func (c *Client) RewriteQuery(ctx context.Context, query string, count int) ([]string, error) {
+	c.logger.Debugf("Reescrevendo query em %d variações: %s", count, query)
+
+	systemPrompt := fmt.Sprintf(`Você reescreve perguntas para melhorar a busca semântica em uma base de documentos.
 
 INSTRUÇÕES:
-1. Responda de forma direta e objetiva, sem mencionar fontes ou contexto
-2. Use informações do contexto quando fornecidas e relevantes
-3. Use conhecimento geral quando o contexto não for relevante para a pergunta
-4. Responda em português brasileiro
-5. Seja preciso, útil e completo
-6. Vá direto ao ponto, sem preâmbulos ou explicações sobre as fontes de informação`
+1. Gere até %d variações da pergunta, combinando paráfrases e subperguntas mais específicas
+2. Expanda perguntas curtas ou ambíguas com o contexto mais provável
+3. Mantenha o idioma da pergunta original
+4. Responda SOMENTE com um array JSON de strings, por exemplo: ["variação 1", "variação 2"]`, count)
+
+	req := openai.ChatCompletionRequest{
+		Model: c.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: systemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("PERGUNTA: %s", query),
+			},
+		},
+		MaxTokens:   300,
+		Temperature: 0.3,
+	}
+
+	resp, err := c.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		c.logger.WithError(err).Error("Erro ao reescrever query")
+		return nil, fmt.Errorf("erro ao reescrever query: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("nenhuma reescrita gerada")
+	}
+
+	var rewrites []string
+	if err := decodeJSONArray(resp.Choices[0].Message.Content, &rewrites); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar reescritas: %w", err)
+	}
+
+	var queries []string
+	for _, rewrite := range rewrites {
+		rewrite = strings.TrimSpace(rewrite)
+		if rewrite == "" || strings.EqualFold(rewrite, query) {
+			continue
+		}
+		queries = append(queries, rewrite)
+		if len(queries) == count {
+			break
+		}
+	}
+
+	return queries, nil
+}
+
+// decodeJSONArray extrai e decodifica o primeiro array JSON presente no texto gerado pelo modelo
+func decodeJSONArray(content string, v interface{}) error {
+	start := strings.Index(content, "[")
+	end := strings.LastIndex(content, "]")
+	if start < 0 || end < start {
+		return fmt.Errorf("array JSON não encontrado: %s", content)
+	}
+
+	return json.Unmarshal([]byte(content[start:end+1]), v)
+}
+
+// GenerateHypotheticalDocument redige uma resposta hipotética para a pergunta, usada apenas
+// como texto de busca (HyDE) e nunca devolvida como resposta final
+func (c *Client) GenerateHypotheticalDocument(ctx context.Context, query string) (string, error) {
+	c.logger.Debugf("Gerando documento hipotético para query: %s", query)
+
+	systemPrompt := `Você escreve trechos de documentação que responderiam a uma pergunta.
+
+INSTRUÇÕES:
+1. Escreva um parágrafo curto, no estilo de um documento de referência, que responda à pergunta
+2. Não mencione a pergunta nem que o texto é hipotético
+3. Use o mesmo idioma da pergunta`
+
+	req := openai.ChatCompletionRequest{
+		Model: c.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: systemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("PERGUNTA: %s", query),
+			},
+		},
+		MaxTokens:   300,
+		Temperature: 0.2,
+	}
+
+	resp, err := c.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		c.logger.WithError(err).Error("Erro ao gerar documento hipotético")
+		return "", fmt.Errorf("erro ao gerar documento hipotético: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("nenhum documento hipotético gerado")
+	}
+
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// CondenseQuestion reescreve uma pergunta de acompanhamento como pergunta independente,
+// usando o histórico da conversa para resolver referências ("e quanto tempo assa?")
+func (c *Client) CondenseQuestion(ctx context.Context, history []models.ChatMessage, question string) (string, error) {
+	c.logger.Debugf("Condensando pergunta com %d mensagens de histórico: %s", len(history), question)
+
+	var historyParts []string
+	for _, message := range history {
+		role := "Usuário"
+		if message.Role == models.ChatRoleAssistant {
+			role = "Assistente"
+		}
+		historyParts = append(historyParts, fmt.Sprintf("%s: %s", role, message.Content))
+	}
+
+	systemPrompt := `Você reescreve a última pergunta de uma conversa como uma pergunta independente.
+
+INSTRUÇÕES:
+1. Resolva pronomes e referências usando o histórico da conversa
+2. Não responda a pergunta, apenas reescreva
+3. Se a pergunta já for independente, repita-a sem alterações
+4. Mantenha o idioma da pergunta original
+5. Responda SOMENTE com a pergunta reescrita`
+
+	userPrompt := fmt.Sprintf(`HISTÓRICO:
+%s
+
+ÚLTIMA PERGUNTA: %s
+
+PERGUNTA INDEPENDENTE:`, strings.Join(historyParts, "\n"), question)
 
 	req := openai.ChatCompletionRequest{
 		Model: c.model,
@@ -100,22 +545,138 @@ INSTRUÇÕES:
 				Content: userPrompt,
 			},
 		},
-		MaxTokens:   1000,
-		Temperature: 0.1,
+		MaxTokens:   200,
+		Temperature: zeroTemperature,
 	}
 
 	resp, err := c.client.CreateChatCompletion(ctx, req)
 	if err != nil {
-		c.logger.WithError(err).Error("Erro ao gerar resposta")
-		return "", fmt.Errorf("erro ao gerar resposta: %w", err)
+		c.logger.WithError(err).Error("Erro ao condensar pergunta")
+		return "", fmt.Errorf("erro ao condensar pergunta: %w", err)
 	}
 
 	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("nenhuma resposta gerada")
+		return "", fmt.Errorf("nenhuma pergunta condensada gerada")
 	}
 
-	answer := resp.Choices[0].Message.Content
-	c.logger.Debugf("Resposta gerada com %d caracteres", len(answer))
+	condensed := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if condensed == "" {
+		return question, nil
+	}
+	return condensed, nil
+}
+
+// TranslateQuery traduz a pergunta para o idioma dos documentos, para que a busca semântica
+// encontre documentos escritos em outro idioma
+func (c *Client) TranslateQuery(ctx context.Context, query string, targetLanguage string) (string, error) {
+	c.logger.Debugf("Traduzindo query para %s: %s", targetLanguage, query)
+
+	systemPrompt := fmt.Sprintf(`Você traduz perguntas para melhorar a busca semântica em uma base de documentos.
+
+INSTRUÇÕES:
+1. Traduza a pergunta para %s
+2. Preserve nomes próprios, termos técnicos e números
+3. Não responda a pergunta, apenas traduza
+4. Responda SOMENTE com a pergunta traduzida`, language.Name(targetLanguage))
+
+	req := openai.ChatCompletionRequest{
+		Model: c.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: systemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("PERGUNTA: %s", query),
+			},
+		},
+		MaxTokens:   200,
+		Temperature: zeroTemperature,
+	}
+
+	resp, err := c.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		c.logger.WithError(err).Error("Erro ao traduzir query")
+		return "", fmt.Errorf("erro ao traduzir query: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("nenhuma tradução gerada")
+	}
+
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// RouteCandidate é uma base de conhecimento oferecida ao classificador de roteamento
+type RouteCandidate struct {
+	Name        string
+	Description string
+}
+
+// ClassifyQuery escolhe, pelas descrições, as bases de conhecimento que podem responder a pergunta.
+// Nomes desconhecidos retornados pelo modelo são descartados.
+func (c *Client) ClassifyQuery(ctx context.Context, query string, candidates []RouteCandidate) ([]string, error) {
+	c.logger.Debugf("Classificando query entre %d bases de conhecimento: %s", len(candidates), query)
+
+	var basesText strings.Builder
+	known := make(map[string]bool, len(candidates))
+	for _, candidate := range candidates {
+		basesText.WriteString(fmt.Sprintf("- %s: %s\n", candidate.Name, candidate.Description))
+		known[candidate.Name] = true
+	}
+
+	systemPrompt := fmt.Sprintf(`Você direciona perguntas para as bases de conhecimento capazes de respondê-las.
+
+BASES DISPONÍVEIS:
+%s
+INSTRUÇÕES:
+1. Escolha a base mais adequada à pergunta, pela descrição
+2. Inclua outras bases apenas se a pergunta abranger claramente mais de um assunto
+3. Use somente nomes da lista acima
+4. Responda SOMENTE com um array JSON de nomes, por exemplo: ["base"]`, basesText.String())
+
+	req := openai.ChatCompletionRequest{
+		Model: c.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: systemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("PERGUNTA: %s", query),
+			},
+		},
+		MaxTokens:   100,
+		Temperature: zeroTemperature,
+	}
+
+	resp, err := c.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		c.logger.WithError(err).Error("Erro ao classificar query")
+		return nil, fmt.Errorf("erro ao classificar query: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("nenhuma classificação gerada")
+	}
+
+	var names []string
+	if err := decodeJSONArray(resp.Choices[0].Message.Content, &names); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar classificação: %w", err)
+	}
+
+	var selected []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if !known[name] || seen[name] {
+			continue
+		}
+		seen[name] = true
+		selected = append(selected, name)
+	}
 
-	return answer, nil
+	return selected, nil
 }
@@ -0,0 +1,234 @@
+package prompts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/language"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrTemplateNotFound indica que não existe template com o nome informado
+var ErrTemplateNotFound = errors.New("template de prompt não encontrado")
+
+// fallbackLanguage é o idioma usado quando o template não tem arquivo para o idioma pedido
+const fallbackLanguage = language.PortugueseBR
+
+// templateExt é a extensão dos arquivos de template (<dir>/<nome>/<idioma>.tmpl)
+const templateExt = ".tmpl"
+
+// Store carrega os templates de prompt de um diretório. Cada subdiretório é um template nomeado,
+// com um arquivo por idioma; a versão é derivada do conteúdo dos arquivos.
+type Store struct {
+	dir         string
+	defaultName string
+	logger      *logrus.Logger
+
+	mu          sync.RWMutex
+	templates   map[string]*Template
+	fingerprint string
+}
+
+// NewStore carrega os templates do diretório informado. O template padrão precisa existir.
+func NewStore(dir, defaultName string, logger *logrus.Logger) (*Store, error) {
+	s := &Store{
+		dir:         dir,
+		defaultName: defaultName,
+		logger:      logger,
+	}
+
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get retorna o template com o nome informado; nome vazio retorna o template padrão
+func (s *Store) Get(name string) (*Template, error) {
+	if name == "" {
+		name = s.defaultName
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tmpl, ok := s.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+	return tmpl, nil
+}
+
+// List retorna os templates carregados, ordenados por nome
+func (s *Store) List() []Info {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	infos := make([]Info, 0, len(s.templates))
+	for _, tmpl := range s.templates {
+		infos = append(infos, tmpl.Info(s.defaultName))
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// Reload relê todos os templates do diretório. Se algum arquivo for inválido, os templates
+// carregados anteriormente continuam em uso.
+func (s *Store) Reload() error {
+	fingerprint, err := s.scan()
+	if err != nil {
+		return err
+	}
+
+	templates, err := loadTemplates(s.dir)
+	if err != nil {
+		return err
+	}
+	if _, ok := templates[s.defaultName]; !ok {
+		return fmt.Errorf("%w: template padrão '%s' ausente em %s", ErrTemplateNotFound, s.defaultName, s.dir)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, tmpl := range templates {
+		if previous, ok := s.templates[name]; !ok || previous.Version != tmpl.Version {
+			s.logger.Infof("Template de prompt '%s' carregado (versão %s)", name, tmpl.Version)
+		}
+	}
+	s.templates = templates
+	s.fingerprint = fingerprint
+	return nil
+}
+
+// Watch verifica periodicamente o diretório e recarrega os templates quando algum arquivo muda,
+// até o contexto ser cancelado. Uma mudança inválida é registrada uma única vez.
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.mu.RLock()
+	last := s.fingerprint
+	s.mu.RUnlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fingerprint, err := s.scan()
+			if err != nil {
+				s.logger.WithError(err).Warn("Erro ao verificar templates de prompt")
+				continue
+			}
+			if fingerprint == last {
+				continue
+			}
+			last = fingerprint
+
+			if err := s.Reload(); err != nil {
+				s.logger.WithError(err).Error("Erro ao recarregar templates de prompt; mantendo a versão anterior")
+			}
+		}
+	}
+}
+
+// scan resume nomes, tamanhos e datas de modificação dos arquivos de template para detectar mudanças
+func (s *Store) scan() (string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*", "*"+templateExt))
+	if err != nil {
+		return "", fmt.Errorf("erro ao listar templates de prompt: %w", err)
+	}
+	sort.Strings(files)
+
+	var parts []string
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", fmt.Errorf("erro ao verificar template %s: %w", file, err)
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d:%d", file, info.Size(), info.ModTime().UnixNano()))
+	}
+	return strings.Join(parts, "|"), nil
+}
+
+// loadTemplates lê e compila os templates de cada subdiretório
+func loadTemplates(dir string) (map[string]*Template, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler diretório de templates %s: %w", dir, err)
+	}
+
+	templates := make(map[string]*Template)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		tmpl, err := loadTemplate(filepath.Join(dir, entry.Name()), entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if tmpl != nil {
+			templates[tmpl.Name] = tmpl
+		}
+	}
+	return templates, nil
+}
+
+// loadTemplate compila os arquivos de idioma de um template; retorna nil se o diretório não tiver templates
+func loadTemplate(dir, name string) (*Template, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+templateExt))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar template %s: %w", name, err)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	sort.Strings(files)
+
+	hash := sha256.New()
+	byLanguage := make(map[string]*template.Template)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler template %s: %w", file, err)
+		}
+
+		lang := strings.TrimSuffix(filepath.Base(file), templateExt)
+		if !language.IsSupported(lang) {
+			return nil, fmt.Errorf("template %s: idioma '%s' não suportado (use %s)", file, lang, strings.Join(language.Supported, ", "))
+		}
+		parsed, err := template.New(name + "/" + lang).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("erro ao compilar template %s: %w", file, err)
+		}
+		for _, block := range []string{systemBlock, userBlock, repairBlock} {
+			if parsed.Lookup(block) == nil {
+				return nil, fmt.Errorf("template %s não define o bloco '%s'", file, block)
+			}
+		}
+
+		byLanguage[lang] = parsed
+		hash.Write([]byte(lang))
+		hash.Write(content)
+	}
+
+	return &Template{
+		Name:       name,
+		Version:    hex.EncodeToString(hash.Sum(nil))[:12],
+		byLanguage: byLanguage,
+	}, nil
+}
@@ -0,0 +1,123 @@
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Blocos que todo arquivo de template deve definir
+const (
+	systemBlock = "system"
+	userBlock   = "user"
+	repairBlock = "repair"
+)
+
+// Template é uma versão imutável de um template nomeado, com um template compilado por idioma.
+// Recarregar o Store cria novos Templates, então uma requisição em andamento mantém a versão que obteve.
+type Template struct {
+	Name       string
+	Version    string
+	byLanguage map[string]*template.Template
+}
+
+// Info descreve um template carregado
+type Info struct {
+	Name      string   `json:"name"`
+	Version   string   `json:"version"`
+	Languages []string `json:"languages"`
+	Default   bool     `json:"default"`
+}
+
+// Document é um documento do contexto, numerado a partir de 1
+type Document struct {
+	Index   int
+	Source  string
+	Score   float32
+	Content string
+}
+
+// Data são os dados disponíveis nos blocos "system" e "user"
+type Data struct {
+	Query     string
+	Documents []Document
+	// HasContext indica que há documentos no contexto
+	HasContext bool
+	// Citations pede referências [n] às passagens numeradas
+	Citations bool
+	// Policy é a política de resposta (grounded_only, grounded_preferred ou open)
+	Policy string
+	// InsufficientContextAnswer é o marcador pedido quando o contexto não basta em grounded_only
+	InsufficientContextAnswer string
+	Language                  string
+	// CrossLingual indica que o contexto pode estar em outro idioma
+	CrossLingual bool
+	// ResponseSchema é o JSON Schema da resposta estruturada, quando houver
+	ResponseSchema string
+}
+
+// RepairData são os dados disponíveis no bloco "repair"
+type RepairData struct {
+	Violations []string
+}
+
+// Render gera as mensagens de sistema e do usuário no idioma informado
+func (t *Template) Render(lang string, data Data) (system string, user string, err error) {
+	tmpl := t.forLanguage(lang)
+
+	if system, err = execute(tmpl, systemBlock, data); err != nil {
+		return "", "", err
+	}
+	if user, err = execute(tmpl, userBlock, data); err != nil {
+		return "", "", err
+	}
+	return system, user, nil
+}
+
+// RenderRepair gera a mensagem que pede ao modelo a correção de uma resposta estruturada inválida
+func (t *Template) RenderRepair(lang string, data RepairData) (string, error) {
+	return execute(t.forLanguage(lang), repairBlock, data)
+}
+
+// Info descreve o template; defaultName é o nome do template padrão do Store
+func (t *Template) Info(defaultName string) Info {
+	languages := make([]string, 0, len(t.byLanguage))
+	for lang := range t.byLanguage {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+
+	return Info{
+		Name:      t.Name,
+		Version:   t.Version,
+		Languages: languages,
+		Default:   t.Name == defaultName,
+	}
+}
+
+// forLanguage retorna o template do idioma, recorrendo ao português e, por fim, a qualquer idioma disponível
+func (t *Template) forLanguage(lang string) *template.Template {
+	if tmpl, ok := t.byLanguage[lang]; ok {
+		return tmpl
+	}
+	if tmpl, ok := t.byLanguage[fallbackLanguage]; ok {
+		return tmpl
+	}
+
+	languages := make([]string, 0, len(t.byLanguage))
+	for lang := range t.byLanguage {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return t.byLanguage[languages[0]]
+}
+
+func execute(tmpl *template.Template, block string, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, block, data); err != nil {
+		return "", fmt.Errorf("erro ao renderizar bloco '%s' do template %s: %w", block, tmpl.Name(), err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -3,12 +3,15 @@ package qdrant
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/marcopollivier/rag-go-ex01/internal/models"
 	"github.com/sirupsen/logrus"
 )
@@ -17,17 +20,23 @@ type Client struct {
 	baseURL        string
 	httpClient     *http.Client
 	collectionName string
-	logger         *logrus.Logger
+	// params são os parâmetros usados ao criar a coleção quando ela ainda não existe
+	params models.CollectionParams
+	// tenant restringe todas as operações aos pontos marcados com este tenant (vazio: sem restrição)
+	tenant string
+	logger *logrus.Logger
 }
 
-// NewClient cria um novo cliente Qdrant usando HTTP API
-func NewClient(host string, port int, collectionName string, logger *logrus.Logger) (*Client, error) {
+// NewClient cria um novo cliente Qdrant usando HTTP API. Se a coleção não existir, ela é criada com
+// os parâmetros informados (nil usa vetores de 1536 dimensões e distância Cosine).
+func NewClient(host string, port int, collectionName string, params *models.CollectionParams, logger *logrus.Logger) (*Client, error) {
 	baseURL := fmt.Sprintf("http://%s:%d", host, port)
 
 	c := &Client{
 		baseURL:        baseURL,
 		httpClient:     &http.Client{Timeout: 30 * time.Second},
 		collectionName: collectionName,
+		params:         withDefaults(params),
 		logger:         logger,
 	}
 
@@ -39,6 +48,53 @@ func NewClient(host string, port int, collectionName string, logger *logrus.Logg
 	return c, nil
 }
 
+// WithCollection retorna um cliente para outra coleção no mesmo servidor, criando-a se necessário
+func (c *Client) WithCollection(ctx context.Context, collectionName string) (*Client, error) {
+	scoped := *c
+	scoped.collectionName = collectionName
+
+	if err := scoped.ensureCollection(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao garantir coleção: %w", err)
+	}
+	return &scoped, nil
+}
+
+// WithTenant retorna um cliente da mesma coleção restrito aos pontos do tenant: documentos indexados
+// são marcados com o tenant e buscas, listagens, leituras, remoções e contagens filtram por ele
+func (c *Client) WithTenant(tenant string) *Client {
+	scoped := *c
+	scoped.tenant = tenant
+	return &scoped
+}
+
+// CollectionName retorna o nome da coleção do cliente
+func (c *Client) CollectionName() string {
+	return c.collectionName
+}
+
+// tenantField é o campo do payload que identifica o tenant dono do ponto
+const tenantField = "tenant"
+
+// scopeFilter acrescenta ao filtro a condição do tenant, quando o cliente é restrito a um
+func (c *Client) scopeFilter(filter map[string]interface{}) map[string]interface{} {
+	if c.tenant == "" {
+		return filter
+	}
+
+	condition := map[string]interface{}{
+		"key":   tenantField,
+		"match": map[string]interface{}{"value": c.tenant},
+	}
+
+	scoped := make(map[string]interface{}, len(filter)+1)
+	for key, value := range filter {
+		scoped[key] = value
+	}
+	must, _ := filter["must"].([]map[string]interface{})
+	scoped["must"] = append(append([]map[string]interface{}{}, must...), condition)
+	return scoped
+}
+
 // Collection structures
 type CollectionConfig struct {
 	Vectors struct {
@@ -51,11 +107,15 @@ type CreateCollectionRequest struct {
 	Vectors struct {
 		Size     int    `json:"size"`
 		Distance string `json:"distance"`
+		OnDisk   bool   `json:"on_disk,omitempty"`
 	} `json:"vectors"`
+	OnDiskPayload      bool                   `json:"on_disk_payload,omitempty"`
+	HNSWConfig         map[string]interface{} `json:"hnsw_config,omitempty"`
+	QuantizationConfig interface{}            `json:"quantization_config,omitempty"`
 }
 
 type PointStruct struct {
-	ID      string                 `json:"id"`
+	ID      models.PointID         `json:"id"`
 	Vector  []float32              `json:"vector"`
 	Payload map[string]interface{} `json:"payload"`
 }
@@ -65,32 +125,62 @@ type UpsertRequest struct {
 }
 
 type SearchRequest struct {
-	Vector      []float32 `json:"vector"`
-	Limit       int       `json:"limit"`
-	Threshold   float32   `json:"score_threshold,omitempty"`
-	WithPayload bool      `json:"with_payload"`
+	Vector      []float32              `json:"vector"`
+	Limit       int                    `json:"limit"`
+	Threshold   float32                `json:"score_threshold,omitempty"`
+	WithPayload bool                   `json:"with_payload"`
+	WithVector  bool                   `json:"with_vector"`
+	Filter      map[string]interface{} `json:"filter,omitempty"`
+}
+
+// ScoredPoint representa um ponto retornado por search ou recommend
+type ScoredPoint struct {
+	ID      models.PointID         `json:"id"`
+	Score   float32                `json:"score"`
+	Payload map[string]interface{} `json:"payload"`
+	Vector  []float32              `json:"vector,omitempty"`
 }
 
 type SearchResponse struct {
-	Result []struct {
-		ID      string                 `json:"id"`
-		Score   float32                `json:"score"`
-		Payload map[string]interface{} `json:"payload"`
-	} `json:"result"`
+	Result []ScoredPoint `json:"result"`
+}
+
+// RecommendRequest representa uma requisição à API de recomendação do Qdrant
+type RecommendRequest struct {
+	Positive    []models.PointID       `json:"positive"`
+	Negative    []models.PointID       `json:"negative,omitempty"`
+	Limit       int                    `json:"limit"`
+	Threshold   float32                `json:"score_threshold,omitempty"`
+	WithPayload bool                   `json:"with_payload"`
+	Filter      map[string]interface{} `json:"filter,omitempty"`
+}
+
+// SearchParams agrupa os parâmetros de uma busca por similaridade
+type SearchParams struct {
+	Limit      int
+	Threshold  float32
+	WithVector bool
+	Filter     models.SearchFilter
 }
 
 type CollectionInfoResponse struct {
 	Status string `json:"status"`
 	Result struct {
-		Status      string `json:"status"`
-		PointsCount int    `json:"points_count"`
-		Config      struct {
+		Status              string `json:"status"`
+		PointsCount         int    `json:"points_count"`
+		IndexedVectorsCount int    `json:"indexed_vectors_count"`
+		SegmentsCount       int    `json:"segments_count"`
+		Config              struct {
 			Params struct {
 				Vectors struct {
 					Size     int    `json:"size"`
 					Distance string `json:"distance"`
+					OnDisk   bool   `json:"on_disk"`
 				} `json:"vectors"`
+				OnDiskPayload bool `json:"on_disk_payload"`
 			} `json:"params"`
+			HNSWConfig         map[string]interface{} `json:"hnsw_config"`
+			QuantizationConfig interface{}            `json:"quantization_config"`
 		} `json:"config"`
 	} `json:"result"`
 }
@@ -109,41 +199,93 @@ func (c *Client) ensureCollection(ctx context.Context) error {
 
 	if resp.StatusCode == http.StatusOK {
 		c.logger.Infof("Coleção '%s' já existe", c.collectionName)
-		return nil
+		return c.ensurePayloadIndexes(ctx)
 	}
 
-	c.logger.Infof("Criando coleção '%s'", c.collectionName)
+	return c.createCollection(ctx)
+}
+
+// payloadIndexes lista os campos do payload indexados e seus tipos
+var payloadIndexes = map[string]string{
+	"created":   "datetime",
+	"source":    "keyword",
+	tenantField: "keyword",
+	parentField: "keyword",
+}
 
-	// Criar coleção
-	createReq := CreateCollectionRequest{}
-	createReq.Vectors.Size = 1536 // OpenAI embeddings
-	createReq.Vectors.Distance = "Cosine"
+// ensurePayloadIndexes cria (de forma idempotente) os índices de payload usados nos filtros
+func (c *Client) ensurePayloadIndexes(ctx context.Context) error {
+	for field, schema := range payloadIndexes {
+		indexReq := map[string]interface{}{
+			"field_name":   field,
+			"field_schema": schema,
+		}
 
-	jsonData, err := json.Marshal(createReq)
-	if err != nil {
-		return fmt.Errorf("erro ao serializar requisição: %w", err)
+		jsonData, err := json.Marshal(indexReq)
+		if err != nil {
+			return fmt.Errorf("erro ao serializar índice: %w", err)
+		}
+
+		url := fmt.Sprintf("%s/collections/%s/index?wait=true", c.baseURL, c.collectionName)
+		req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return fmt.Errorf("erro ao criar requisição: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("erro ao criar índice '%s': %w", field, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("erro ao criar índice '%s': status %d, body: %s", field, resp.StatusCode, string(body))
+		}
+
+		c.logger.Infof("Índice de payload '%s' (%s) garantido", field, schema)
 	}
 
-	createURL := fmt.Sprintf("%s/collections/%s", c.baseURL, c.collectionName)
-	req, err := http.NewRequest("PUT", createURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("erro ao criar requisição: %w", err)
+	return nil
+}
+
+// buildFilter converte um SearchFilter no filtro de payload do Qdrant (nil quando vazio)
+func buildFilter(filter models.SearchFilter) map[string]interface{} {
+	var must []map[string]interface{}
+
+	if filter.CreatedAfter != nil || filter.CreatedBefore != nil {
+		dateRange := map[string]interface{}{}
+		if filter.CreatedAfter != nil {
+			dateRange["gte"] = filter.CreatedAfter.UTC().Format(time.RFC3339)
+		}
+		if filter.CreatedBefore != nil {
+			dateRange["lt"] = filter.CreatedBefore.UTC().Format(time.RFC3339)
+		}
+		must = append(must, map[string]interface{}{
+			"key":   "created",
+			"range": dateRange,
+		})
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err = c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("erro ao criar coleção: %w", err)
+	if filter.Source != "" {
+		must = append(must, map[string]interface{}{
+			"key":   "source",
+			"match": map[string]interface{}{"value": filter.Source},
+		})
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("erro ao criar coleção: status %d, body: %s", resp.StatusCode, string(body))
+	for key, value := range filter.Metadata {
+		must = append(must, map[string]interface{}{
+			"key":   "metadata_" + key,
+			"match": map[string]interface{}{"value": value},
+		})
 	}
 
-	c.logger.Infof("Coleção '%s' criada com sucesso", c.collectionName)
-	return nil
+	if len(must) == 0 {
+		return nil
+	}
+	return map[string]interface{}{"must": must}
 }
 
 // IndexDocument indexa um documento no Qdrant
@@ -154,7 +296,7 @@ func (c *Client) IndexDocument(ctx context.Context, doc models.Document, embeddi
 	payload := map[string]interface{}{
 		"content": doc.Content,
 		"source":  doc.Source,
-		"created": doc.Created.Format("2006-01-02T15:04:05Z"),
+		"created": doc.Created.UTC().Format(time.RFC3339), // datetime indexado pelo Qdrant
 	}
 
 	// Adicionar metadata
@@ -162,8 +304,15 @@ func (c *Client) IndexDocument(ctx context.Context, doc models.Document, embeddi
 		payload[fmt.Sprintf("metadata_%s", key)] = value
 	}
 
+	if c.tenant != "" {
+		if err := c.EnsureNotForeign(ctx, []string{doc.ID}); err != nil {
+			return err
+		}
+		payload[tenantField] = c.tenant
+	}
+
 	point := PointStruct{
-		ID:      doc.ID,
+		ID:      models.PointID(doc.ID),
 		Vector:  embedding,
 		Payload: payload,
 	}
@@ -202,13 +351,20 @@ func (c *Client) IndexDocument(ctx context.Context, doc models.Document, embeddi
 
 // SearchSimilar busca documentos similares
 func (c *Client) SearchSimilar(ctx context.Context, queryEmbedding []float32, topK int, threshold float32) ([]models.RelevantDocument, error) {
-	c.logger.Debugf("Buscando %d documentos similares com threshold %.2f", topK, threshold)
+	return c.Search(ctx, queryEmbedding, SearchParams{Limit: topK, Threshold: threshold})
+}
+
+// Search busca documentos similares com parâmetros avançados
+func (c *Client) Search(ctx context.Context, queryEmbedding []float32, params SearchParams) ([]models.RelevantDocument, error) {
+	c.logger.Debugf("Buscando %d documentos similares com threshold %.2f", params.Limit, params.Threshold)
 
 	searchReq := SearchRequest{
 		Vector:      queryEmbedding,
-		Limit:       topK,
-		Threshold:   threshold,
+		Limit:       params.Limit,
+		Threshold:   params.Threshold,
 		WithPayload: true,
+		WithVector:  params.WithVector,
+		Filter:      c.scopeFilter(buildFilter(params.Filter)),
 	}
 
 	jsonData, err := json.Marshal(searchReq)
@@ -236,104 +392,203 @@ func (c *Client) SearchSimilar(ctx context.Context, queryEmbedding []float32, to
 
 	c.logger.Infof("Resposta do Qdrant: %d resultados encontrados", len(searchResponse.Result))
 
-	var relevantDocs []models.RelevantDocument
-	for _, hit := range searchResponse.Result {
-		c.logger.Infof("Processando hit ID: %s, Score: %.4f", hit.ID, hit.Score)
-
-		// Extrair dados do payload
-		content := ""
-		source := ""
-		created := time.Time{}
-		metadata := make(map[string]string)
-
-		if contentVal, ok := hit.Payload["content"]; ok {
-			if str, ok := contentVal.(string); ok {
-				content = str
-				c.logger.Infof("Content encontrado: %d caracteres", len(content))
-			} else {
-				c.logger.Warn("Content não é uma string")
-			}
-		} else {
-			c.logger.Warn("Content não encontrado no payload")
-		}
+	relevantDocs := c.scoredPointsToDocuments(searchResponse.Result)
 
-		if sourceVal, ok := hit.Payload["source"]; ok {
-			if str, ok := sourceVal.(string); ok {
-				source = str
-			}
-		}
-		if createdVal, ok := hit.Payload["created"]; ok {
-			if str, ok := createdVal.(string); ok {
-				if t, err := time.Parse("2006-01-02T15:04:05Z", str); err == nil {
-					created = t
-				}
-			}
-		}
+	c.logger.Debugf("Encontrados %d documentos relevantes", len(relevantDocs))
+	return relevantDocs, nil
+}
 
-		// Extrair metadata
-		for key, value := range hit.Payload {
-			if len(key) > 9 && key[:9] == "metadata_" {
-				metaKey := key[9:]
-				if str, ok := value.(string); ok {
-					metadata[metaKey] = str
-				}
-			}
-		}
+// ErrDocumentNotFound indica que o documento solicitado não existe na coleção
+var ErrDocumentNotFound = errors.New("documento não encontrado")
+
+// Recommend busca documentos semelhantes aos exemplos positivos e distantes dos negativos,
+// usando os vetores já armazenados. Os próprios exemplos nunca são retornados.
+func (c *Client) Recommend(ctx context.Context, positive, negative []string, limit int, threshold float32) ([]models.RelevantDocument, error) {
+	c.logger.Debugf("Recomendando %d documentos a partir de %d exemplos positivos e %d negativos", limit, len(positive), len(negative))
 
-		doc := models.Document{
-			ID:       hit.ID,
-			Content:  content,
-			Source:   source,
-			Metadata: metadata,
-			Created:  created,
+	examples := append(append([]string{}, positive...), negative...)
+
+	// Exemplos de outro tenant não podem servir de referência, mesmo sem aparecer no resultado
+	if c.tenant != "" {
+		if err := c.ensureOwned(ctx, examples); err != nil {
+			return nil, err
 		}
+	}
 
-		c.logger.Infof("Documento criado - ID: %s, Content: %d chars, Source: %s", doc.ID, len(doc.Content), doc.Source)
+	recommendReq := RecommendRequest{
+		Positive:    pointIDs(positive),
+		Negative:    pointIDs(negative),
+		Limit:       limit,
+		Threshold:   threshold,
+		WithPayload: true,
+		Filter: c.scopeFilter(map[string]interface{}{
+			"must_not": []map[string]interface{}{
+				{"has_id": pointIDs(examples)},
+			},
+		}),
+	}
 
-		relevantDocs = append(relevantDocs, models.RelevantDocument{
-			Document: doc,
-			Score:    hit.Score,
-		})
+	jsonData, err := json.Marshal(recommendReq)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar recomendação: %w", err)
 	}
 
-	c.logger.Debugf("Encontrados %d documentos relevantes", len(relevantDocs))
+	url := fmt.Sprintf("%s/collections/%s/points/recommend", c.baseURL, c.collectionName)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.WithError(err).Error("Erro ao recomendar documentos")
+		return nil, fmt.Errorf("erro ao recomendar documentos: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrDocumentNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("erro na recomendação: status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var searchResponse SearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&searchResponse); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar resposta: %w", err)
+	}
+
+	relevantDocs := c.scoredPointsToDocuments(searchResponse.Result)
+
+	c.logger.Debugf("Encontrados %d documentos recomendados", len(relevantDocs))
 	return relevantDocs, nil
 }
 
-// DeleteDocument remove um documento do índice
-func (c *Client) DeleteDocument(ctx context.Context, docID string) error {
-	c.logger.Debugf("Removendo documento ID: %s", docID)
+// PointResponse representa a resposta da consulta de um ponto por ID
+type PointResponse struct {
+	Result PointStruct `json:"result"`
+}
+
+// GetDocument busca um documento pelo ID
+func (c *Client) GetDocument(ctx context.Context, docID string) (models.Document, error) {
+	c.logger.Debugf("Buscando documento ID: %s", docID)
+
+	url := fmt.Sprintf("%s/collections/%s/points/%s", c.baseURL, c.collectionName, docID)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return models.Document{}, fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.WithError(err).Errorf("Erro ao buscar documento %s", docID)
+		return models.Document{}, fmt.Errorf("erro ao buscar documento: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return models.Document{}, ErrDocumentNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return models.Document{}, fmt.Errorf("erro ao buscar documento: status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var pointResponse PointResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pointResponse); err != nil {
+		return models.Document{}, fmt.Errorf("erro ao decodificar resposta: %w", err)
+	}
+
+	if c.tenant != "" && pointResponse.Result.Payload[tenantField] != c.tenant {
+		return models.Document{}, ErrDocumentNotFound
+	}
+
+	return c.pointToDocument(pointResponse.Result), nil
+}
+
+// ensureOwned confere que todos os pontos informados existem e pertencem ao tenant do cliente
+func (c *Client) ensureOwned(ctx context.Context, ids []string) error {
+	unique := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
 
-	deleteReq := map[string]interface{}{
-		"points": []string{docID},
+	count, err := c.Count(ctx, map[string]interface{}{
+		"must": []map[string]interface{}{
+			{"has_id": pointIDs(ids)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if count != len(unique) {
+		return ErrDocumentNotFound
 	}
+	return nil
+}
 
-	jsonData, err := json.Marshal(deleteReq)
+// CountRequest representa uma requisição de contagem de pontos
+type CountRequest struct {
+	Filter map[string]interface{} `json:"filter,omitempty"`
+	Exact  bool                   `json:"exact"`
+}
+
+// CountResponse representa a resposta de uma contagem de pontos
+type CountResponse struct {
+	Result struct {
+		Count int `json:"count"`
+	} `json:"result"`
+}
+
+// Count conta os pontos que atendem ao filtro (restrito ao tenant do cliente)
+func (c *Client) Count(ctx context.Context, filter map[string]interface{}) (int, error) {
+	jsonData, err := json.Marshal(CountRequest{Filter: c.scopeFilter(filter), Exact: true})
 	if err != nil {
-		return fmt.Errorf("erro ao serializar delete: %w", err)
+		return 0, fmt.Errorf("erro ao serializar contagem: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/collections/%s/points/delete", c.baseURL, c.collectionName)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	url := fmt.Sprintf("%s/collections/%s/points/count", c.baseURL, c.collectionName)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("erro ao criar requisição: %w", err)
+		return 0, fmt.Errorf("erro ao criar requisição: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		c.logger.WithError(err).Errorf("Erro ao remover documento %s", docID)
-		return fmt.Errorf("erro ao remover documento: %w", err)
+		return 0, fmt.Errorf("erro ao contar pontos: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("erro ao remover documento: status %d, body: %s", resp.StatusCode, string(body))
+		return 0, fmt.Errorf("erro na contagem: status %d, body: %s", resp.StatusCode, string(body))
 	}
 
-	c.logger.Debugf("Documento %s removido com sucesso", docID)
-	return nil
+	var countResponse CountResponse
+	if err := json.NewDecoder(resp.Body).Decode(&countResponse); err != nil {
+		return 0, fmt.Errorf("erro ao decodificar resposta: %w", err)
+	}
+	return countResponse.Result.Count, nil
+}
+
+// scoredPointsToDocuments converte pontos pontuados em documentos relevantes
+func (c *Client) scoredPointsToDocuments(points []ScoredPoint) []models.RelevantDocument {
+	var relevantDocs []models.RelevantDocument
+	for _, hit := range points {
+		c.logger.Debugf("Processando hit ID: %s, Score: %.4f", hit.ID, hit.Score)
+
+		doc := c.pointToDocument(PointStruct{ID: hit.ID, Payload: hit.Payload})
+
+		relevantDocs = append(relevantDocs, models.RelevantDocument{
+			Document: doc,
+			Score:    hit.Score,
+			Vector:   hit.Vector,
+		})
+	}
+	return relevantDocs
 }
 
 // ScrollRequest representa uma requisição de scroll
@@ -341,87 +596,90 @@ type ScrollRequest struct {
 	Limit       int                    `json:"limit"`
 	WithPayload bool                   `json:"with_payload"`
 	WithVector  bool                   `json:"with_vector"`
-	Offset      *string                `json:"offset,omitempty"`
+	Offset      json.RawMessage        `json:"offset,omitempty"`
 	Filter      map[string]interface{} `json:"filter,omitempty"`
 }
 
 // ScrollResponse representa a resposta de um scroll
 type ScrollResponse struct {
 	Result struct {
-		Points         []PointStruct `json:"points"`
-		NextPageOffset *string       `json:"next_page_offset"`
+		Points         []PointStruct   `json:"points"`
+		NextPageOffset json.RawMessage `json:"next_page_offset"`
 	} `json:"result"`
 }
 
-// GetAllDocuments retorna todos os documentos da coleção usando scroll
-func (c *Client) GetAllDocuments(ctx context.Context, limit int) ([]models.Document, error) {
-	c.logger.Infof("Buscando todos os documentos da coleção '%s'", c.collectionName)
+// ErrInvalidCursor indica um cursor de paginação malformado
+var ErrInvalidCursor = errors.New("cursor de paginação inválido")
 
-	scrollReq := ScrollRequest{
-		Limit:       limit,
-		WithPayload: true,
-		WithVector:  false,
+// encodeCursor converte o offset do Qdrant em um cursor opaco (vazio quando não há próxima página)
+func encodeCursor(offset json.RawMessage) string {
+	if len(offset) == 0 || string(offset) == "null" {
+		return ""
 	}
+	return base64.RawURLEncoding.EncodeToString(offset)
+}
 
-	jsonData, err := json.Marshal(scrollReq)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao serializar scroll: %w", err)
+// decodeCursor converte um cursor opaco de volta no offset do Qdrant, que só pode ser um ID de
+// ponto: um inteiro sem sinal ou um UUID
+func decodeCursor(cursor string) (json.RawMessage, error) {
+	if cursor == "" {
+		return nil, nil
 	}
 
-	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.baseURL, c.collectionName)
-	resp, err := c.httpClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao fazer scroll: %w", err)
+		return nil, ErrInvalidCursor
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("erro no scroll: status %d, body: %s", resp.StatusCode, string(body))
+	var id models.PointID
+	if err := json.Unmarshal(raw, &id); err != nil {
+		return nil, ErrInvalidCursor
 	}
-
-	var scrollResponse ScrollResponse
-	if err := json.NewDecoder(resp.Body).Decode(&scrollResponse); err != nil {
-		return nil, fmt.Errorf("erro ao decodificar resposta: %w", err)
+	if !id.IsNumeric() {
+		parsed, err := uuid.Parse(string(id))
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		id = models.PointID(parsed.String())
 	}
 
-	var documents []models.Document
-	for _, point := range scrollResponse.Result.Points {
-		doc := c.pointToDocument(point)
-		documents = append(documents, doc)
+	offset, err := json.Marshal(id)
+	if err != nil {
+		return nil, ErrInvalidCursor
 	}
-
-	c.logger.Infof("Encontrados %d documentos na coleção", len(documents))
-	return documents, nil
+	return json.RawMessage(offset), nil
 }
 
-// GetDocumentsBySource busca documentos por fonte específica
-func (c *Client) GetDocumentsBySource(ctx context.Context, source string, limit int) ([]models.Document, error) {
-	c.logger.Infof("Buscando documentos da fonte '%s'", source)
-
-	scrollReq := ScrollRequest{
-		Limit:       limit,
-		WithPayload: true,
-		WithVector:  false,
-		Filter: map[string]interface{}{
-			"must": []map[string]interface{}{
-				{
-					"key": "source",
-					"match": map[string]interface{}{
-						"value": source,
-					},
+// sourceFilter monta o filtro de payload por fonte
+func sourceFilter(source string) map[string]interface{} {
+	return map[string]interface{}{
+		"must": []map[string]interface{}{
+			{
+				"key": "source",
+				"match": map[string]interface{}{
+					"value": source,
 				},
 			},
 		},
 	}
+}
 
+// scroll executa uma página de scroll na coleção
+func (c *Client) scroll(ctx context.Context, scrollReq ScrollRequest) (*ScrollResponse, error) {
+	scrollReq.Filter = c.scopeFilter(scrollReq.Filter)
 	jsonData, err := json.Marshal(scrollReq)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao serializar scroll: %w", err)
 	}
 
 	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.baseURL, c.collectionName)
-	resp, err := c.httpClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao fazer scroll: %w", err)
 	}
@@ -437,14 +695,88 @@ func (c *Client) GetDocumentsBySource(ctx context.Context, source string, limit
 		return nil, fmt.Errorf("erro ao decodificar resposta: %w", err)
 	}
 
+	return &scrollResponse, nil
+}
+
+// scrollDocuments busca uma página de documentos a partir do cursor informado
+func (c *Client) scrollDocuments(ctx context.Context, filter map[string]interface{}, limit int, cursor string) ([]models.Document, string, error) {
+	offset, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	scrollResponse, err := c.scroll(ctx, ScrollRequest{
+		Limit:       limit,
+		WithPayload: true,
+		WithVector:  false,
+		Offset:      offset,
+		Filter:      filter,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
 	var documents []models.Document
 	for _, point := range scrollResponse.Result.Points {
 		doc := c.pointToDocument(point)
 		documents = append(documents, doc)
 	}
 
+	return documents, encodeCursor(scrollResponse.Result.NextPageOffset), nil
+}
+
+// GetAllDocuments retorna uma página de documentos da coleção e o cursor da próxima página
+func (c *Client) GetAllDocuments(ctx context.Context, limit int, cursor string) ([]models.Document, string, error) {
+	c.logger.Infof("Buscando todos os documentos da coleção '%s'", c.collectionName)
+
+	documents, nextCursor, err := c.scrollDocuments(ctx, nil, limit, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	c.logger.Infof("Encontrados %d documentos na coleção", len(documents))
+	return documents, nextCursor, nil
+}
+
+// GetDocumentsBySource busca uma página de documentos por fonte específica e o cursor da próxima página
+func (c *Client) GetDocumentsBySource(ctx context.Context, source string, limit int, cursor string) ([]models.Document, string, error) {
+	c.logger.Infof("Buscando documentos da fonte '%s'", source)
+
+	documents, nextCursor, err := c.scrollDocuments(ctx, sourceFilter(source), limit, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
 	c.logger.Infof("Encontrados %d documentos da fonte '%s'", len(documents), source)
-	return documents, nil
+	return documents, nextCursor, nil
+}
+
+// WalkDocuments percorre todas as páginas de documentos (opcionalmente filtrados por fonte),
+// chamando fn para cada documento
+func (c *Client) WalkDocuments(ctx context.Context, source string, pageSize int, fn func(models.Document) error) error {
+	var filter map[string]interface{}
+	if source != "" {
+		filter = sourceFilter(source)
+	}
+
+	cursor := ""
+	for {
+		documents, nextCursor, err := c.scrollDocuments(ctx, filter, pageSize, cursor)
+		if err != nil {
+			return err
+		}
+
+		for _, doc := range documents {
+			if err := fn(doc); err != nil {
+				return err
+			}
+		}
+
+		if nextCursor == "" {
+			return nil
+		}
+		cursor = nextCursor
+	}
 }
 
 // GetCollectionInfo retorna informações sobre a coleção
@@ -468,6 +800,14 @@ func (c *Client) GetCollectionInfo(ctx context.Context) (*CollectionInfoResponse
 		return nil, fmt.Errorf("erro ao decodificar resposta: %w", err)
 	}
 
+	// Em coleção compartilhada, o total informado é apenas o dos pontos do tenant
+	if c.tenant != "" {
+		collectionInfo.Result.PointsCount, err = c.Count(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	c.logger.Infof("Coleção '%s' tem %d pontos", c.collectionName, collectionInfo.Result.PointsCount)
 	return &collectionInfo, nil
 }
@@ -493,7 +833,7 @@ func (c *Client) pointToDocument(point PointStruct) models.Document {
 
 	if createdVal, ok := point.Payload["created"]; ok {
 		if str, ok := createdVal.(string); ok {
-			if t, err := time.Parse("2006-01-02T15:04:05Z", str); err == nil {
+			if t, err := time.Parse(time.RFC3339, str); err == nil {
 				created = t
 			}
 		}
@@ -510,7 +850,7 @@ func (c *Client) pointToDocument(point PointStruct) models.Document {
 	}
 
 	return models.Document{
-		ID:       point.ID,
+		ID:       string(point.ID),
 		Content:  content,
 		Source:   source,
 		Metadata: metadata,
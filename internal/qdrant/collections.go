@@ -0,0 +1,243 @@
+package qdrant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+const (
+	// defaultVectorSize é a dimensão dos embeddings da OpenAI
+	defaultVectorSize = 1536
+	defaultDistance   = "Cosine"
+)
+
+// ErrCollectionExists indica que já existe uma coleção com o nome informado
+var ErrCollectionExists = errors.New("coleção já existe")
+
+// ErrCollectionNotFound indica que a coleção não existe no Qdrant
+var ErrCollectionNotFound = errors.New("coleção não encontrada")
+
+// withDefaults preenche a dimensão e a distância padrão dos vetores
+func withDefaults(params *models.CollectionParams) models.CollectionParams {
+	var p models.CollectionParams
+	if params != nil {
+		p = *params
+	}
+	if p.VectorSize == 0 {
+		p.VectorSize = defaultVectorSize
+	}
+	if p.Distance == "" {
+		p.Distance = defaultDistance
+	}
+	return p
+}
+
+// CreateCollection cria uma coleção nova com os parâmetros informados e retorna o cliente dela,
+// falhando com ErrCollectionExists se o nome já estiver em uso
+func (c *Client) CreateCollection(ctx context.Context, collectionName string, params models.CollectionParams) (*Client, error) {
+	created := *c
+	created.collectionName = collectionName
+	created.params = withDefaults(&params)
+
+	url := fmt.Sprintf("%s/collections/%s", c.baseURL, collectionName)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar coleção: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil, ErrCollectionExists
+	}
+
+	if err := created.createCollection(ctx); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// createCollection cria a coleção do cliente com seus parâmetros e os índices de payload
+func (c *Client) createCollection(ctx context.Context) error {
+	c.logger.Infof("Criando coleção '%s' (%d dimensões, %s)", c.collectionName, c.params.VectorSize, c.params.Distance)
+
+	createReq := CreateCollectionRequest{
+		OnDiskPayload:      c.params.OnDiskPayload,
+		HNSWConfig:         hnswConfig(c.params.HNSW),
+		QuantizationConfig: quantizationConfig(c.params.Quantization),
+	}
+	createReq.Vectors.Size = c.params.VectorSize
+	createReq.Vectors.Distance = c.params.Distance
+	createReq.Vectors.OnDisk = c.params.OnDisk
+
+	if err := c.collectionRequest(ctx, "PUT", createReq); err != nil {
+		return fmt.Errorf("erro ao criar coleção: %w", err)
+	}
+
+	c.logger.Infof("Coleção '%s' criada com sucesso", c.collectionName)
+	return c.ensurePayloadIndexes(ctx)
+}
+
+// UpdateCollection altera os parâmetros ajustáveis da coleção: vetores e payload em disco, índice
+// HNSW e quantização
+func (c *Client) UpdateCollection(ctx context.Context, update models.UpdateCollectionRequest) error {
+	c.logger.Infof("Atualizando coleção '%s'", c.collectionName)
+
+	updateReq := map[string]interface{}{}
+	if update.OnDisk != nil {
+		// O vetor sem nome da coleção é identificado pela chave vazia
+		updateReq["vectors"] = map[string]interface{}{
+			"": map[string]interface{}{"on_disk": *update.OnDisk},
+		}
+	}
+	if update.OnDiskPayload != nil {
+		updateReq["params"] = map[string]interface{}{"on_disk_payload": *update.OnDiskPayload}
+	}
+	if update.HNSW != nil {
+		updateReq["hnsw_config"] = hnswConfig(update.HNSW)
+	}
+	if update.Quantization != nil {
+		updateReq["quantization_config"] = quantizationConfig(update.Quantization)
+	}
+	if len(updateReq) == 0 {
+		return nil
+	}
+
+	if err := c.collectionRequest(ctx, "PATCH", updateReq); err != nil {
+		return fmt.Errorf("erro ao atualizar coleção: %w", err)
+	}
+	return nil
+}
+
+// DeleteCollection remove a coleção e todos os seus pontos
+func (c *Client) DeleteCollection(ctx context.Context) error {
+	c.logger.Infof("Removendo coleção '%s'", c.collectionName)
+
+	if err := c.collectionRequest(ctx, "DELETE", nil); err != nil {
+		return fmt.Errorf("erro ao remover coleção: %w", err)
+	}
+	return nil
+}
+
+// collectionRequest envia uma requisição ao endpoint da coleção do cliente
+func (c *Client) collectionRequest(ctx context.Context, method string, body interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("erro ao serializar requisição: %w", err)
+		}
+		reader = bytes.NewBuffer(jsonData)
+	}
+
+	url := fmt.Sprintf("%s/collections/%s", c.baseURL, c.collectionName)
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrCollectionNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d, body: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// hnswConfig converte a configuração HNSW para o formato do Qdrant (nil quando vazia)
+func hnswConfig(config *models.HNSWConfig) map[string]interface{} {
+	if config == nil {
+		return nil
+	}
+
+	hnsw := map[string]interface{}{}
+	if config.M != nil {
+		hnsw["m"] = *config.M
+	}
+	if config.EfConstruct != nil {
+		hnsw["ef_construct"] = *config.EfConstruct
+	}
+	if config.FullScanThreshold != nil {
+		hnsw["full_scan_threshold"] = *config.FullScanThreshold
+	}
+	if config.OnDisk != nil {
+		hnsw["on_disk"] = *config.OnDisk
+	}
+	if len(hnsw) == 0 {
+		return nil
+	}
+	return hnsw
+}
+
+// quantizationConfig converte a configuração de quantização para o formato do Qdrant
+func quantizationConfig(config *models.QuantizationConfig) interface{} {
+	if config == nil {
+		return nil
+	}
+
+	options := map[string]interface{}{}
+	if config.AlwaysRAM != nil {
+		options["always_ram"] = *config.AlwaysRAM
+	}
+
+	switch config.Type {
+	case models.QuantizationScalar:
+		options["type"] = "int8"
+		if config.Quantile != nil {
+			options["quantile"] = *config.Quantile
+		}
+		return map[string]interface{}{"scalar": options}
+	case models.QuantizationBinary:
+		return map[string]interface{}{"binary": options}
+	case models.QuantizationProduct:
+		options["compression"] = config.Compression
+		if config.Compression == "" {
+			options["compression"] = "x16"
+		}
+		return map[string]interface{}{"product": options}
+	case models.QuantizationDisabled:
+		return "Disabled"
+	default:
+		return nil
+	}
+}
+
+// ForCollection retorna um cliente para outra coleção no mesmo servidor, sem verificar se ela existe
+func (c *Client) ForCollection(collectionName string) *Client {
+	other := *c
+	other.collectionName = collectionName
+	return &other
+}
+
+// WithParams retorna um cliente da mesma coleção que usa os parâmetros informados ao criar coleções
+// derivadas por WithCollection
+func (c *Client) WithParams(params models.CollectionParams) *Client {
+	other := *c
+	other.params = withDefaults(&params)
+	return &other
+}
+
+// Params retorna os parâmetros de criação da coleção do cliente
+func (c *Client) Params() models.CollectionParams {
+	return c.params
+}
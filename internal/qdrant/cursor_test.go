@@ -0,0 +1,83 @@
+package qdrant
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset string
+	}{
+		{name: "offset numérico", offset: `42`},
+		{name: "offset UUID", offset: `"5c56c793-69f3-4fbf-87e6-c4bf54c28c26"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := encodeCursor(json.RawMessage(tt.offset))
+			if cursor == "" {
+				t.Fatal("encodeCursor() retornou cursor vazio")
+			}
+
+			got, err := decodeCursor(cursor)
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			if string(got) != tt.offset {
+				t.Errorf("decodeCursor() = %s, want %s", got, tt.offset)
+			}
+		})
+	}
+}
+
+func TestEncodeCursorWithoutNextPage(t *testing.T) {
+	for _, offset := range []string{"", "null"} {
+		if got := encodeCursor(json.RawMessage(offset)); got != "" {
+			t.Errorf("encodeCursor(%q) = %q, want vazio", offset, got)
+		}
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := []struct {
+		name    string
+		cursor  string
+		want    string
+		wantErr bool
+	}{
+		{name: "cursor vazio é a primeira página", cursor: "", want: ""},
+		{name: "UUID é normalizado", cursor: encode(`"5C56C793-69F3-4FBF-87E6-C4BF54C28C26"`), want: `"5c56c793-69f3-4fbf-87e6-c4bf54c28c26"`},
+		{name: "número em string vira número", cursor: encode(`"7"`), want: `7`},
+		{name: "base64 inválido", cursor: "%%%", wantErr: true},
+		{name: "JSON inválido", cursor: encode(`{`), wantErr: true},
+		{name: "objeto JSON", cursor: encode(`{"id": 1}`), wantErr: true},
+		{name: "número negativo", cursor: encode(`-1`), wantErr: true},
+		{name: "número fracionário", cursor: encode(`1.5`), wantErr: true},
+		{name: "string que não é UUID", cursor: encode(`"pagina-2"`), wantErr: true},
+		{name: "null", cursor: encode(`null`), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.cursor)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Errorf("decodeCursor(%q) error = %v, want ErrInvalidCursor", tt.cursor, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeCursor(%q) error = %v", tt.cursor, err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("decodeCursor(%q) = %s, want %s", tt.cursor, got, tt.want)
+			}
+		})
+	}
+}
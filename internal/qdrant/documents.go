@@ -0,0 +1,175 @@
+package qdrant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+// parentField é o campo do payload que liga um trecho (chunk) ao documento de origem, gravado a
+// partir de metadata.parent_id
+const parentField = "metadata_parent_id"
+
+// documentFilter seleciona o documento e os trechos que apontam para ele
+func documentFilter(docID string) map[string]interface{} {
+	return map[string]interface{}{
+		"should": []map[string]interface{}{
+			{"has_id": pointIDs([]string{docID})},
+			{"key": parentField, "match": map[string]interface{}{"value": docID}},
+		},
+	}
+}
+
+// CountChunks conta os trechos que apontam para o documento (metadata.parent_id)
+func (c *Client) CountChunks(ctx context.Context, docID string) (int, error) {
+	return c.Count(ctx, map[string]interface{}{
+		"must": []map[string]interface{}{
+			{"key": parentField, "match": map[string]interface{}{"value": docID}},
+		},
+	})
+}
+
+// pointSelector seleciona um único ponto; com tenant, a seleção é por filtro, para que o ID de um
+// ponto de outro tenant não tenha efeito
+func (c *Client) pointSelector(docID string) map[string]interface{} {
+	if c.tenant == "" {
+		return map[string]interface{}{"points": pointIDs([]string{docID})}
+	}
+	return map[string]interface{}{
+		"filter": c.scopeFilter(map[string]interface{}{
+			"must": []map[string]interface{}{
+				{"has_id": pointIDs([]string{docID})},
+			},
+		}),
+	}
+}
+
+// DeleteDocument remove um documento do índice junto com os seus trechos, retornando quantos
+// pontos foram removidos (ErrDocumentNotFound quando nenhum)
+func (c *Client) DeleteDocument(ctx context.Context, docID string) (int, error) {
+	c.logger.Debugf("Removendo documento ID: %s", docID)
+
+	filter := documentFilter(docID)
+	count, err := c.Count(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, ErrDocumentNotFound
+	}
+
+	if err := c.pointsRequest(ctx, "delete", map[string]interface{}{"filter": c.scopeFilter(filter)}); err != nil {
+		c.logger.WithError(err).Errorf("Erro ao remover documento %s", docID)
+		return 0, fmt.Errorf("erro ao remover documento: %w", err)
+	}
+
+	c.logger.Debugf("Documento %s removido com sucesso (%d pontos)", docID, count)
+	return count, nil
+}
+
+// SetPayload grava os campos informados no payload do documento, mantendo os demais e o vetor
+func (c *Client) SetPayload(ctx context.Context, docID string, payload map[string]interface{}) error {
+	c.logger.Debugf("Atualizando payload do documento ID: %s", docID)
+
+	setReq := c.pointSelector(docID)
+	setReq["payload"] = payload
+	if err := c.pointsRequest(ctx, "payload", setReq); err != nil {
+		return fmt.Errorf("erro ao atualizar payload: %w", err)
+	}
+	return nil
+}
+
+// DeletePayload remove os campos informados do payload do documento
+func (c *Client) DeletePayload(ctx context.Context, docID string, keys []string) error {
+	c.logger.Debugf("Removendo campos %v do payload do documento ID: %s", keys, docID)
+
+	deleteReq := c.pointSelector(docID)
+	deleteReq["keys"] = keys
+	if err := c.pointsRequest(ctx, "payload/delete", deleteReq); err != nil {
+		return fmt.Errorf("erro ao remover campos do payload: %w", err)
+	}
+	return nil
+}
+
+// pointsRequest envia uma operação sobre os pontos da coleção (POST /points/<operação>),
+// aguardando que ela seja aplicada
+func (c *Client) pointsRequest(ctx context.Context, operation string, body interface{}) error {
+	return c.pointsCall(ctx, "POST", "/"+operation, body)
+}
+
+// pointsCall envia uma requisição ao endpoint de pontos da coleção, aguardando que ela seja aplicada
+func (c *Client) pointsCall(ctx context.Context, method string, path string, body interface{}) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar requisição: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points%s?wait=true", c.baseURL, c.collectionName, path)
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d, body: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// CountByFilter conta os documentos que atendem ao filtro
+func (c *Client) CountByFilter(ctx context.Context, filter models.SearchFilter) (int, error) {
+	return c.Count(ctx, buildFilter(filter))
+}
+
+// DeleteByFilter remove todos os documentos que atendem ao filtro, retornando quantos foram removidos
+func (c *Client) DeleteByFilter(ctx context.Context, filter models.SearchFilter) (int, error) {
+	count, err := c.CountByFilter(ctx, filter)
+	if err != nil || count == 0 {
+		return count, err
+	}
+	scoped := c.scopeFilter(buildFilter(filter))
+
+	c.logger.Infof("Removendo %d documentos por filtro da coleção '%s'", count, c.collectionName)
+	if err := c.pointsRequest(ctx, "delete", map[string]interface{}{"filter": scoped}); err != nil {
+		return 0, fmt.Errorf("erro ao remover documentos por filtro: %w", err)
+	}
+	return count, nil
+}
+
+// UpdatePayloadByFilter grava e remove campos do payload de todos os documentos que atendem ao
+// filtro, retornando quantos foram alterados
+func (c *Client) UpdatePayloadByFilter(ctx context.Context, filter models.SearchFilter, payload map[string]interface{}, removeKeys []string) (int, error) {
+	count, err := c.CountByFilter(ctx, filter)
+	if err != nil || count == 0 {
+		return count, err
+	}
+	scoped := c.scopeFilter(buildFilter(filter))
+
+	c.logger.Infof("Atualizando payload de %d documentos por filtro na coleção '%s'", count, c.collectionName)
+	if len(payload) > 0 {
+		setReq := map[string]interface{}{"filter": scoped, "payload": payload}
+		if err := c.pointsRequest(ctx, "payload", setReq); err != nil {
+			return 0, fmt.Errorf("erro ao atualizar payload por filtro: %w", err)
+		}
+	}
+	if len(removeKeys) > 0 {
+		deleteReq := map[string]interface{}{"filter": scoped, "keys": removeKeys}
+		if err := c.pointsRequest(ctx, "payload/delete", deleteReq); err != nil {
+			return 0, fmt.Errorf("erro ao remover campos do payload por filtro: %w", err)
+		}
+	}
+	return count, nil
+}
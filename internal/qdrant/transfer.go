@@ -0,0 +1,112 @@
+package qdrant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+// ErrForeignPoint indica que um ID informado já pertence a um ponto de outro tenant na coleção compartilhada
+var ErrForeignPoint = errors.New("ID já usado por outro tenant")
+
+// WalkPoints percorre todos os pontos da coleção (restritos ao tenant do cliente), página a página
+// via scroll, com payload e, opcionalmente, vetor. Em cliente restrito a um tenant, o campo do
+// tenant é omitido do payload, para que o arquivo possa ser importado em outro tenant.
+func (c *Client) WalkPoints(ctx context.Context, withVector bool, pageSize int, fn func(PointStruct) error) error {
+	var offset json.RawMessage
+	for {
+		scrollResponse, err := c.scroll(ctx, ScrollRequest{
+			Limit:       pageSize,
+			WithPayload: true,
+			WithVector:  withVector,
+			Offset:      offset,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, point := range scrollResponse.Result.Points {
+			if c.tenant != "" {
+				delete(point.Payload, tenantField)
+			}
+			if err := fn(point); err != nil {
+				return err
+			}
+		}
+
+		next := scrollResponse.Result.NextPageOffset
+		if len(next) == 0 || string(next) == "null" {
+			return nil
+		}
+		offset = next
+	}
+}
+
+// UpsertPoints grava os pontos com os vetores e payloads informados, sem gerar embeddings. Em
+// cliente restrito a um tenant, cada ponto é marcado com o tenant do cliente.
+func (c *Client) UpsertPoints(ctx context.Context, points []PointStruct) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	if c.tenant != "" {
+		ids := make([]string, 0, len(points))
+		for _, point := range points {
+			ids = append(ids, string(point.ID))
+		}
+		if err := c.EnsureNotForeign(ctx, ids); err != nil {
+			return err
+		}
+		for i := range points {
+			if points[i].Payload == nil {
+				points[i].Payload = make(map[string]interface{})
+			}
+			points[i].Payload[tenantField] = c.tenant
+		}
+	}
+
+	if err := c.pointsCall(ctx, "PUT", "", UpsertRequest{Points: points}); err != nil {
+		return fmt.Errorf("erro ao gravar pontos: %w", err)
+	}
+
+	c.logger.Debugf("%d pontos gravados na coleção '%s'", len(points), c.collectionName)
+	return nil
+}
+
+// EnsureNotForeign confere, na coleção compartilhada, que nenhum dos IDs pertence a outro tenant,
+// para que a gravação não sobrescreva pontos alheios. Sem tenant no cliente, não há o que conferir.
+func (c *Client) EnsureNotForeign(ctx context.Context, ids []string) error {
+	if c.tenant == "" || len(ids) == 0 {
+		return nil
+	}
+
+	unscoped := *c
+	unscoped.tenant = ""
+	count, err := unscoped.Count(ctx, map[string]interface{}{
+		"must": []map[string]interface{}{
+			{"has_id": pointIDs(ids)},
+		},
+		"must_not": []map[string]interface{}{
+			{"key": tenantField, "match": map[string]interface{}{"value": c.tenant}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrForeignPoint
+	}
+	return nil
+}
+
+// pointIDs converte os IDs para o formato do Qdrant, com os IDs numéricos como números
+func pointIDs(ids []string) []models.PointID {
+	converted := make([]models.PointID, 0, len(ids))
+	for _, id := range ids {
+		converted = append(converted, models.PointID(id))
+	}
+	return converted
+}
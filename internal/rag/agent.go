@@ -0,0 +1,300 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/openai"
+)
+
+const (
+	// defaultAgentSteps é o limite de passos do agente quando a requisição não informa um
+	defaultAgentSteps = 5
+	// agentPreviewChars limita o conteúdo de cada documento devolvido ao modelo pelas ferramentas
+	agentPreviewChars = 1500
+	// agentListLimit limita os documentos e fontes listados por list_sources
+	agentListLimit = 20
+	// agentSourceScanLimit limita os documentos percorridos ao descobrir as fontes da coleção
+	agentSourceScanLimit = 2000
+)
+
+// errStopWalk interrompe a varredura da coleção ao atingir o limite
+var errStopWalk = errors.New("varredura interrompida")
+
+// agentTools são as ferramentas oferecidas ao modelo no modo agente
+var agentTools = []openai.AgentTool{
+	{
+		Name:        "search_documents",
+		Description: "Busca semântica nas bases de conhecimento. Retorna os documentos mais relevantes com ID, base, fonte, score e conteúdo.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"query": {"type": "string", "description": "Texto da busca"},
+				"top_k": {"type": "integer", "minimum": 1, "maximum": 10, "description": "Número de documentos (padrão 5)"},
+				"collection": {"type": "string", "description": "Base de conhecimento (opcional; sem ela a busca é roteada)"}
+			},
+			"required": ["query"]
+		}`),
+	},
+	{
+		Name:        "get_document",
+		Description: "Retorna o conteúdo completo de um documento pelo ID.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"id": {"type": "string", "description": "ID do documento"},
+				"collection": {"type": "string", "description": "Base de conhecimento do documento (padrão: a base padrão)"}
+			},
+			"required": ["id"]
+		}`),
+	},
+	{
+		Name:        "list_sources",
+		Description: "Sem argumentos, lista as fontes de documentos da coleção. Com source, lista os documentos dessa fonte.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"source": {"type": "string", "description": "Fonte cujos documentos devem ser listados"},
+				"collection": {"type": "string", "description": "Base de conhecimento (padrão: a base padrão)"}
+			}
+		}`),
+	},
+	{
+		Name:        "collection_info",
+		Description: "Lista as bases de conhecimento com descrição, número de documentos, dimensão dos vetores e métrica de distância.",
+		Parameters:  json.RawMessage(`{"type": "object", "properties": {}}`),
+	},
+}
+
+// Agent responde uma pergunta deixando o modelo decidir quais ferramentas de busca chamar, em vários
+// passos, e retorna a resposta com o rastro das chamadas
+func (s *Service) Agent(ctx context.Context, req models.AgentRequest) (*models.AgentResponse, error) {
+	startTime := time.Now()
+	s.logger.Infof("Executando agente: %s", req.Query)
+
+	if req.MaxSteps == 0 {
+		req.MaxSteps = defaultAgentSteps
+	}
+	queryReq := models.QueryRequest{Query: req.Query, Language: req.Language}
+	s.applyLanguageDefaults(&queryReq)
+
+	if err := s.consumeQuery(ctx); err != nil {
+		return nil, err
+	}
+
+	var steps []models.AgentStep
+	execute := func(ctx context.Context, step int, call openai.ToolCall) (string, error) {
+		callStart := time.Now()
+		output, summary, err := s.executeAgentTool(ctx, call)
+
+		arguments := json.RawMessage(call.Arguments)
+		if !json.Valid(arguments) {
+			arguments, _ = json.Marshal(call.Arguments)
+		}
+		entry := models.AgentStep{
+			Step:       step,
+			Tool:       call.Name,
+			Arguments:  arguments,
+			Summary:    summary,
+			DurationMs: time.Since(callStart).Milliseconds(),
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		steps = append(steps, entry)
+
+		s.logger.Debugf("Agente passo %d: %s(%s)", step, call.Name, call.Arguments)
+		return output, err
+	}
+
+	result, err := s.openaiClient.RunAgent(ctx, req.Query, queryReq.Language, agentTools, req.MaxSteps, execute)
+	if err != nil {
+		return nil, err
+	}
+
+	processingTime := time.Since(startTime)
+	s.logger.Infof("Agente concluído em %v com %d chamadas de ferramentas", processingTime, len(steps))
+
+	return &models.AgentResponse{
+		Answer:           result.Answer,
+		Language:         queryReq.Language,
+		Steps:            steps,
+		StepLimitReached: result.StepLimitReached,
+		Usage:            &result.Usage,
+		ProcessingTimeMs: processingTime.Milliseconds(),
+	}, nil
+}
+
+// executeAgentTool executa uma ferramenta e retorna o texto devolvido ao modelo e um resumo para o rastro
+func (s *Service) executeAgentTool(ctx context.Context, call openai.ToolCall) (string, string, error) {
+	var args struct {
+		Query      string `json:"query"`
+		TopK       int    `json:"top_k"`
+		ID         string `json:"id"`
+		Source     string `json:"source"`
+		Collection string `json:"collection"`
+	}
+	if strings.TrimSpace(call.Arguments) != "" {
+		if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+			return "", "", fmt.Errorf("argumentos inválidos: %w", err)
+		}
+	}
+
+	switch call.Name {
+	case "search_documents":
+		return s.agentSearch(ctx, args.Query, args.TopK, args.Collection)
+	case "get_document":
+		return s.agentGetDocument(ctx, args.Collection, args.ID)
+	case "list_sources":
+		if args.Source != "" {
+			return s.agentListSource(ctx, args.Collection, args.Source)
+		}
+		return s.agentListSources(ctx, args.Collection)
+	case "collection_info":
+		return s.agentCollectionInfo(ctx)
+	default:
+		return "", "", fmt.Errorf("ferramenta desconhecida: %s", call.Name)
+	}
+}
+
+func (s *Service) agentSearch(ctx context.Context, query string, topK int, collection string) (string, string, error) {
+	if strings.TrimSpace(query) == "" {
+		return "", "", fmt.Errorf("query é obrigatória")
+	}
+	if topK < 1 || topK > 10 {
+		topK = 5
+	}
+
+	searchReq := models.QueryRequest{Query: query, TopK: topK}
+	if collection != "" {
+		searchReq.Collections = []string{collection}
+	}
+
+	resp, err := s.search(ctx, models.SearchRequest{QueryRequest: searchReq})
+	if err != nil {
+		return "", "", err
+	}
+	if len(resp.Results) == 0 {
+		return "Nenhum documento encontrado.", "0 documentos", nil
+	}
+
+	var b strings.Builder
+	ids := make([]string, 0, len(resp.Results))
+	for i, result := range resp.Results {
+		fmt.Fprintf(&b, "[%d] ID: %s | Base: %s | Fonte: %s | Score: %.3f\n%s\n\n",
+			i+1, result.Document.ID, result.Collection, result.Document.Source, result.Score, preview(result.Document.Content, agentPreviewChars))
+		ids = append(ids, result.Document.ID)
+	}
+	return b.String(), fmt.Sprintf("%d documentos: %s", len(ids), strings.Join(ids, ", ")), nil
+}
+
+func (s *Service) agentGetDocument(ctx context.Context, collection string, id string) (string, string, error) {
+	if id == "" {
+		return "", "", fmt.Errorf("id é obrigatório")
+	}
+
+	doc, err := s.GetDocument(ctx, collection, id)
+	if err != nil {
+		return "", "", err
+	}
+	output := fmt.Sprintf("ID: %s | Fonte: %s | Criado em: %s\n%s",
+		doc.ID, doc.Source, doc.Created.Format(time.RFC3339), doc.Content)
+	return output, fmt.Sprintf("documento %s (%s)", doc.ID, doc.Source), nil
+}
+
+func (s *Service) agentListSource(ctx context.Context, collection string, source string) (string, string, error) {
+	docs, next, err := s.GetDocumentsBySource(ctx, collection, source, agentListLimit, "")
+	if err != nil {
+		return "", "", err
+	}
+	if len(docs) == 0 {
+		return fmt.Sprintf("Nenhum documento na fonte %s.", source), "0 documentos", nil
+	}
+
+	var b strings.Builder
+	for _, doc := range docs {
+		fmt.Fprintf(&b, "- ID: %s | %s\n", doc.ID, preview(doc.Content, 200))
+	}
+	if next != "" {
+		fmt.Fprintf(&b, "(lista limitada aos primeiros %d documentos)\n", agentListLimit)
+	}
+	return b.String(), fmt.Sprintf("%d documentos da fonte %s", len(docs), source), nil
+}
+
+func (s *Service) agentListSources(ctx context.Context, collection string) (string, string, error) {
+	base, err := s.base(ctx, collection)
+	if err != nil {
+		return "", "", err
+	}
+
+	counts := make(map[string]int)
+	scanned := 0
+	err = base.Client.WalkDocuments(ctx, "", exportPageSize, func(doc models.Document) error {
+		counts[doc.Source]++
+		scanned++
+		if scanned >= agentSourceScanLimit {
+			return errStopWalk
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopWalk) {
+		return "", "", err
+	}
+	if len(counts) == 0 {
+		return "A coleção está vazia.", "0 fontes", nil
+	}
+
+	sources := make([]string, 0, len(counts))
+	for source := range counts {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool { return counts[sources[i]] > counts[sources[j]] })
+
+	var b strings.Builder
+	for i, source := range sources {
+		if i == agentListLimit {
+			fmt.Fprintf(&b, "(mais %d fontes omitidas)\n", len(sources)-agentListLimit)
+			break
+		}
+		fmt.Fprintf(&b, "- %s (%d documentos)\n", source, counts[source])
+	}
+	if errors.Is(err, errStopWalk) {
+		fmt.Fprintf(&b, "(contagem baseada nos primeiros %d documentos)\n", agentSourceScanLimit)
+	}
+	return b.String(), fmt.Sprintf("%d fontes", len(sources)), nil
+}
+
+func (s *Service) agentCollectionInfo(ctx context.Context) (string, string, error) {
+	var b strings.Builder
+	total := 0
+	for _, configured := range s.bases.all() {
+		base, err := s.scoped(ctx, configured)
+		if err != nil {
+			return "", "", err
+		}
+		info, err := base.Client.GetCollectionInfo(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		fmt.Fprintf(&b, "- %s: %s | Documentos: %d | Dimensão dos vetores: %d | Distância: %s | Status: %s\n",
+			base.Name, base.Description, info.Result.PointsCount, info.Result.Config.Params.Vectors.Size,
+			info.Result.Config.Params.Vectors.Distance, info.Result.Status)
+		total += info.Result.PointsCount
+	}
+	return b.String(), fmt.Sprintf("%d documentos", total), nil
+}
+
+// preview corta o texto em até max caracteres
+func preview(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max]) + "..."
+}
@@ -0,0 +1,154 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
+)
+
+const (
+	// defaultChatSessionTTL é a expiração padrão, por inatividade, das sessões de conversa
+	defaultChatSessionTTL = 30 * time.Minute
+	// maxHistoryMessages limita o histórico enviado ao modelo a cada turno
+	maxHistoryMessages = 6
+)
+
+// Chat responde uma mensagem dentro de uma sessão de conversa. Perguntas de acompanhamento são
+// condensadas em perguntas independentes antes da recuperação, e o histórico recente é enviado ao modelo.
+func (s *Service) Chat(ctx context.Context, req models.ChatRequest) (*models.ChatResponse, error) {
+	turn, err := s.startChatTurn(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.query(ctx, turn.queryReq, turn.history)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.finishChatTurn(turn, response.Answer); err != nil {
+		return nil, err
+	}
+
+	return &models.ChatResponse{
+		SessionID:       turn.sessionID,
+		StandaloneQuery: turn.queryReq.Query,
+		QueryResponse:   *response,
+	}, nil
+}
+
+// ChatStream responde uma mensagem da conversa em streaming. Emite primeiro o evento "session",
+// seguido dos mesmos eventos de QueryStream. A mensagem só entra no histórico se a geração terminar.
+func (s *Service) ChatStream(ctx context.Context, req models.ChatRequest, emit func(event string, data interface{}) error) error {
+	if err := checkStreamable(req.QueryRequest); err != nil {
+		return err
+	}
+
+	turn, err := s.startChatTurn(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if err := emit(StreamEventSession, models.StreamSessionEvent{
+		SessionID:       turn.sessionID,
+		StandaloneQuery: turn.queryReq.Query,
+	}); err != nil {
+		return err
+	}
+
+	gen, err := s.queryStream(ctx, turn.queryReq, turn.history, emit)
+	if err != nil {
+		return err
+	}
+
+	return s.finishChatTurn(turn, gen.answer)
+}
+
+// chatTurn reúne o estado de uma mensagem em processamento na conversa
+type chatTurn struct {
+	owner     string
+	sessionID string
+	message   string
+	history   []models.ChatMessage
+	queryReq  models.QueryRequest
+}
+
+// startChatTurn resolve a sessão, recorta o histórico e condensa a pergunta de acompanhamento
+func (s *Service) startChatTurn(ctx context.Context, req models.ChatRequest) (*chatTurn, error) {
+	owner := tenant.IDFromContext(ctx)
+	session, err := s.chatSession(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Infof("Mensagem na sessão %s (%d mensagens anteriores)", session.ID, len(session.Messages))
+
+	history := session.Messages
+	if len(history) > maxHistoryMessages {
+		history = history[len(history)-maxHistoryMessages:]
+	}
+
+	message := req.Query
+	standaloneQuery := message
+	if len(history) > 0 {
+		standaloneQuery, err = s.openaiClient.CondenseQuestion(ctx, history, message)
+		if err != nil {
+			s.logger.WithError(err).Error("Erro ao condensar pergunta")
+			return nil, fmt.Errorf("erro ao condensar pergunta: %w", err)
+		}
+		s.logger.Infof("Pergunta condensada: %s", standaloneQuery)
+	}
+
+	queryReq := req.QueryRequest
+	queryReq.Query = standaloneQuery
+
+	return &chatTurn{
+		owner:     owner,
+		sessionID: session.ID,
+		message:   message,
+		history:   history,
+		queryReq:  queryReq,
+	}, nil
+}
+
+// finishChatTurn registra a mensagem do usuário e a resposta no histórico da sessão
+func (s *Service) finishChatTurn(turn *chatTurn, answer string) error {
+	now := time.Now()
+	return s.chatStore.Append(turn.owner, turn.sessionID,
+		models.ChatMessage{Role: models.ChatRoleUser, Content: turn.message, Timestamp: now},
+		models.ChatMessage{Role: models.ChatRoleAssistant, Content: answer, Timestamp: now},
+	)
+}
+
+// chatSession retorna a sessão informada na requisição ou cria uma nova
+func (s *Service) chatSession(ctx context.Context, req models.ChatRequest) (models.ChatSession, error) {
+	if req.SessionID != "" {
+		return s.chatStore.Get(tenant.IDFromContext(ctx), req.SessionID)
+	}
+	return s.CreateChatSession(ctx, time.Duration(req.TTLSeconds)*time.Second), nil
+}
+
+// CreateChatSession cria uma sessão de conversa vazia do tenant da requisição; ttl zero usa o TTL padrão
+func (s *Service) CreateChatSession(ctx context.Context, ttl time.Duration) models.ChatSession {
+	session := s.chatStore.Create(tenant.IDFromContext(ctx), ttl)
+	s.logger.Infof("Sessão de conversa %s criada (expira em %s)", session.ID, session.ExpiresAt.Format(time.RFC3339))
+	return session
+}
+
+// GetChatSession retorna uma sessão de conversa com seu histórico
+func (s *Service) GetChatSession(ctx context.Context, id string) (models.ChatSession, error) {
+	return s.chatStore.Get(tenant.IDFromContext(ctx), id)
+}
+
+// ListChatSessions lista as sessões de conversa ativas do tenant da requisição
+func (s *Service) ListChatSessions(ctx context.Context) []models.ChatSessionSummary {
+	return s.chatStore.List(tenant.IDFromContext(ctx))
+}
+
+// DeleteChatSession remove uma sessão de conversa
+func (s *Service) DeleteChatSession(ctx context.Context, id string) error {
+	s.logger.Infof("Removendo sessão de conversa %s", id)
+	return s.chatStore.Delete(tenant.IDFromContext(ctx), id)
+}
@@ -0,0 +1,82 @@
+package rag
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+// citationPattern reconhece referências como [1] ou [1, 3]
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// extractCitations mapeia as referências [n] da resposta para os documentos do contexto (numerados a partir de 1).
+// Cada citação traz o trecho da resposta que a referência sustenta. Números sem documento correspondente são
+// devolvidos separadamente.
+func extractCitations(answer string, contextDocs []models.RelevantDocument) ([]models.Citation, []int) {
+	citations := []models.Citation{}
+	var invalid []int
+
+	runes := []rune(answer)
+	for _, match := range citationPattern.FindAllStringSubmatchIndex(answer, -1) {
+		markerStart := utf8.RuneCountInString(answer[:match[0]])
+		spanStart, spanEnd := citedSpan(runes, markerStart)
+
+		for _, part := range strings.Split(answer[match[2]:match[3]], ",") {
+			number, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+
+			if number < 1 || number > len(contextDocs) {
+				invalid = append(invalid, number)
+				continue
+			}
+
+			doc := contextDocs[number-1].Document
+			citations = append(citations, models.Citation{
+				Passage:    number,
+				DocumentID: doc.ID,
+				Source:     doc.Source,
+				Start:      spanStart,
+				End:        spanEnd,
+				Text:       string(runes[spanStart:spanEnd]),
+			})
+		}
+	}
+
+	return citations, invalid
+}
+
+// citedSpan encontra a frase da resposta que antecede o marcador de citação, em posições de caracteres
+func citedSpan(runes []rune, markerStart int) (int, int) {
+	end := markerStart
+	for end > 0 && unicode.IsSpace(runes[end-1]) {
+		end--
+	}
+
+	// Marcador após a pontuação final ("frase. [1]"): a frase termina na pontuação
+	searchFrom := end - 1
+	if searchFrom >= 0 && isSentenceBoundary(runes[searchFrom]) {
+		searchFrom--
+	}
+
+	start := searchFrom
+	for start >= 0 && !isSentenceBoundary(runes[start]) && runes[start] != ']' {
+		start--
+	}
+	start++
+
+	for start < end && unicode.IsSpace(runes[start]) {
+		start++
+	}
+
+	return start, end
+}
+
+func isSentenceBoundary(r rune) bool {
+	return r == '.' || r == '!' || r == '?' || r == '\n'
+}
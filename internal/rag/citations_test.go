@@ -0,0 +1,83 @@
+package rag
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+func TestExtractCitations(t *testing.T) {
+	contextDocs := []models.RelevantDocument{
+		{Document: models.Document{ID: "doc-1", Source: "receitas.txt"}},
+		{Document: models.Document{ID: "doc-2", Source: "tempos.txt"}},
+	}
+
+	tests := []struct {
+		name        string
+		answer      string
+		want        []models.Citation
+		wantInvalid []int
+	}{
+		{
+			name:   "sem citações",
+			answer: "O brigadeiro leva chocolate.",
+			want:   []models.Citation{},
+		},
+		{
+			name:   "citação ao fim da frase",
+			answer: "O brigadeiro leva chocolate [1].",
+			want: []models.Citation{
+				{Passage: 1, DocumentID: "doc-1", Source: "receitas.txt", Start: 0, End: 27, Text: "O brigadeiro leva chocolate"},
+			},
+		},
+		{
+			name:   "citação após a pontuação",
+			answer: "Primeira frase. Leva dez minutos. [2]",
+			want: []models.Citation{
+				{Passage: 2, DocumentID: "doc-2", Source: "tempos.txt", Start: 16, End: 33, Text: "Leva dez minutos."},
+			},
+		},
+		{
+			name:   "várias passagens no mesmo marcador",
+			answer: "Leva chocolate e dez minutos [1, 2].",
+			want: []models.Citation{
+				{Passage: 1, DocumentID: "doc-1", Source: "receitas.txt", Start: 0, End: 28, Text: "Leva chocolate e dez minutos"},
+				{Passage: 2, DocumentID: "doc-2", Source: "tempos.txt", Start: 0, End: 28, Text: "Leva chocolate e dez minutos"},
+			},
+		},
+		{
+			name:   "marcadores seguidos delimitam os trechos",
+			answer: "Leva chocolate [1] e fica pronto em dez minutos [2].",
+			want: []models.Citation{
+				{Passage: 1, DocumentID: "doc-1", Source: "receitas.txt", Start: 0, End: 14, Text: "Leva chocolate"},
+				{Passage: 2, DocumentID: "doc-2", Source: "tempos.txt", Start: 19, End: 47, Text: "e fica pronto em dez minutos"},
+			},
+		},
+		{
+			name:   "posições em caracteres com acentuação",
+			answer: "Não há açúcar [1].",
+			want: []models.Citation{
+				{Passage: 1, DocumentID: "doc-1", Source: "receitas.txt", Start: 0, End: 13, Text: "Não há açúcar"},
+			},
+		},
+		{
+			name:        "passagens inexistentes são devolvidas à parte",
+			answer:      "Informação inventada [3, 0].",
+			want:        []models.Citation{},
+			wantInvalid: []int{3, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, invalid := extractCitations(tt.answer, contextDocs)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractCitations() = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(invalid, tt.wantInvalid) {
+				t.Errorf("extractCitations() invalid = %v, want %v", invalid, tt.wantInvalid)
+			}
+		})
+	}
+}
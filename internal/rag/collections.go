@@ -0,0 +1,316 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/knowledge"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/qdrant"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
+)
+
+// ErrKnowledgeBaseNotFound indica que a base de conhecimento informada não existe
+var ErrKnowledgeBaseNotFound = errors.New("base de conhecimento não encontrada")
+
+// ErrKnowledgeBaseExists indica que o nome ou a coleção da nova base já estão em uso
+var ErrKnowledgeBaseExists = errors.New("base de conhecimento já existe")
+
+// validName restringe nomes de bases e coleções criadas pela API, que aparecem em URLs
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// CreateKnowledgeBase cria a coleção no Qdrant com os parâmetros informados e registra a nova base
+func (s *Service) CreateKnowledgeBase(ctx context.Context, req models.CreateCollectionRequest) (*models.CollectionDescription, error) {
+	kb := s.bases
+	kb.adminMu.Lock()
+	defer kb.adminMu.Unlock()
+
+	collection := req.Collection
+	if collection == "" {
+		collection = req.Name
+	}
+	if !validName.MatchString(req.Name) || !validName.MatchString(collection) {
+		return nil, fmt.Errorf("%w: nome e coleção devem conter apenas letras, números, '_' ou '-'", ErrInvalidRequest)
+	}
+	for _, existing := range kb.all() {
+		if existing.Name == req.Name {
+			return nil, fmt.Errorf("%w: %s", ErrKnowledgeBaseExists, req.Name)
+		}
+		if existing.Collection == collection {
+			return nil, fmt.Errorf("%w: coleção '%s' usada pela base '%s'", ErrKnowledgeBaseExists, collection, existing.Name)
+		}
+		for _, tenantCollection := range s.tenantCollections(existing) {
+			if tenantCollection == collection {
+				return nil, fmt.Errorf("%w: coleção '%s' reservada a um tenant da base '%s'", ErrKnowledgeBaseExists, collection, existing.Name)
+			}
+		}
+	}
+
+	s.logger.Infof("Criando base de conhecimento '%s' na coleção '%s'", req.Name, collection)
+	client, err := kb.all()[0].Client.CreateCollection(ctx, collection, req.CollectionParams)
+	if errors.Is(err, qdrant.ErrCollectionExists) {
+		return nil, fmt.Errorf("%w: coleção '%s' já existe no Qdrant", ErrKnowledgeBaseExists, collection)
+	}
+	if err != nil {
+		s.logger.WithError(err).Error("Erro ao criar coleção")
+		return nil, err
+	}
+
+	params := client.Params()
+	base := &KnowledgeBase{
+		Base: knowledge.Base{
+			Name:         req.Name,
+			Description:  req.Description,
+			Collection:   collection,
+			AnswerPolicy: req.AnswerPolicy,
+			Config:       &params,
+		},
+		Client: client,
+	}
+	kb.add(base)
+
+	if err := s.saveKnowledgeBases(); err != nil {
+		return nil, err
+	}
+	return s.DescribeKnowledgeBase(ctx, req.Name)
+}
+
+// DescribeKnowledgeBase retorna a configuração e o estado da coleção da base, restrita ao tenant da requisição
+func (s *Service) DescribeKnowledgeBase(ctx context.Context, name string) (*models.CollectionDescription, error) {
+	base, err := s.namedBase(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := base.Client.GetCollectionInfo(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("Erro ao obter informações da coleção")
+		return nil, err
+	}
+
+	result := info.Result
+	return &models.CollectionDescription{
+		Name:                base.Name,
+		Description:         base.Description,
+		Collection:          base.Collection,
+		AnswerPolicy:        s.basePolicy(base),
+		Status:              result.Status,
+		PointsCount:         result.PointsCount,
+		IndexedVectorsCount: result.IndexedVectorsCount,
+		SegmentsCount:       result.SegmentsCount,
+		VectorSize:          result.Config.Params.Vectors.Size,
+		Distance:            result.Config.Params.Vectors.Distance,
+		OnDisk:              result.Config.Params.Vectors.OnDisk,
+		OnDiskPayload:       result.Config.Params.OnDiskPayload,
+		HNSW:                result.Config.HNSWConfig,
+		Quantization:        result.Config.QuantizationConfig,
+	}, nil
+}
+
+// namedBase retorna a base pelo nome, restrita ao tenant da requisição (ErrKnowledgeBaseNotFound
+// quando não existe)
+func (s *Service) namedBase(ctx context.Context, name string) (*KnowledgeBase, error) {
+	base, ok := s.bases.lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKnowledgeBaseNotFound, name)
+	}
+	return s.scoped(ctx, base)
+}
+
+// UpdateKnowledgeBase altera a descrição da base e os parâmetros ajustáveis da coleção. Os parâmetros
+// também são aplicados às coleções próprias dos tenants e às que forem criadas depois.
+func (s *Service) UpdateKnowledgeBase(ctx context.Context, name string, req models.UpdateCollectionRequest) (*models.CollectionDescription, error) {
+	kb := s.bases
+	kb.adminMu.Lock()
+	defer kb.adminMu.Unlock()
+
+	base, ok := kb.lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKnowledgeBaseNotFound, name)
+	}
+
+	s.logger.Infof("Atualizando base de conhecimento '%s'", name)
+	if err := base.Client.UpdateCollection(ctx, req); err != nil {
+		s.logger.WithError(err).Error("Erro ao atualizar coleção")
+		return nil, err
+	}
+	for _, collection := range s.tenantCollections(base) {
+		err := base.Client.ForCollection(collection).UpdateCollection(ctx, req)
+		if err != nil && !errors.Is(err, qdrant.ErrCollectionNotFound) {
+			s.logger.WithError(err).Errorf("Erro ao atualizar coleção '%s'", collection)
+			return nil, err
+		}
+	}
+
+	params := mergeCollectionParams(base.Client.Params(), req)
+	updated := &KnowledgeBase{Base: base.Base, Client: base.Client.WithParams(params)}
+	updated.Config = &params
+	if req.Description != nil {
+		updated.Description = *req.Description
+	}
+	if req.AnswerPolicy != nil {
+		updated.AnswerPolicy = *req.AnswerPolicy
+	}
+	kb.replace(updated)
+
+	if err := s.saveKnowledgeBases(); err != nil {
+		return nil, err
+	}
+	return s.DescribeKnowledgeBase(ctx, name)
+}
+
+// DropKnowledgeBase remove a base e apaga sua coleção, incluindo as coleções próprias dos tenants.
+// A última base não pode ser removida, pois o serviço sempre precisa de uma base padrão.
+func (s *Service) DropKnowledgeBase(ctx context.Context, name string) error {
+	kb := s.bases
+	kb.adminMu.Lock()
+	defer kb.adminMu.Unlock()
+
+	base, ok := kb.lookup(name)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrKnowledgeBaseNotFound, name)
+	}
+	if len(kb.all()) == 1 {
+		return fmt.Errorf("%w: a última base de conhecimento não pode ser removida", ErrInvalidRequest)
+	}
+
+	s.logger.Infof("Removendo base de conhecimento '%s'", name)
+	collections := append([]string{base.Collection}, s.tenantCollections(base)...)
+	for _, collection := range collections {
+		err := base.Client.ForCollection(collection).DeleteCollection(ctx)
+		if err != nil && !errors.Is(err, qdrant.ErrCollectionNotFound) {
+			s.logger.WithError(err).Errorf("Erro ao remover coleção '%s'", collection)
+			return err
+		}
+	}
+	kb.remove(name)
+
+	return s.saveKnowledgeBases()
+}
+
+// tenantCollections retorna os nomes das coleções próprias dos tenants na base
+func (s *Service) tenantCollections(base *KnowledgeBase) []string {
+	var collections []string
+	for _, t := range s.config.Tenants.All() {
+		if t.Isolation == tenant.IsolationCollection {
+			collections = append(collections, tenantCollection(base, t))
+		}
+	}
+	return collections
+}
+
+// saveKnowledgeBases grava as bases no arquivo de configuração, quando há um, para que as mudanças
+// sobrevivam a reinícios
+func (s *Service) saveKnowledgeBases() error {
+	if s.config.KnowledgeBasesFile == "" {
+		return nil
+	}
+
+	var bases []knowledge.Base
+	for _, base := range s.bases.all() {
+		bases = append(bases, base.Base)
+	}
+	if err := knowledge.Save(s.config.KnowledgeBasesFile, bases); err != nil {
+		s.logger.WithError(err).Error("Erro ao salvar bases de conhecimento")
+		return err
+	}
+	return nil
+}
+
+// mergeCollectionParams aplica a atualização aos parâmetros de criação da coleção
+func mergeCollectionParams(params models.CollectionParams, req models.UpdateCollectionRequest) models.CollectionParams {
+	if req.OnDisk != nil {
+		params.OnDisk = *req.OnDisk
+	}
+	if req.OnDiskPayload != nil {
+		params.OnDiskPayload = *req.OnDiskPayload
+	}
+	if req.HNSW != nil {
+		hnsw := models.HNSWConfig{}
+		if params.HNSW != nil {
+			hnsw = *params.HNSW
+		}
+		if req.HNSW.M != nil {
+			hnsw.M = req.HNSW.M
+		}
+		if req.HNSW.EfConstruct != nil {
+			hnsw.EfConstruct = req.HNSW.EfConstruct
+		}
+		if req.HNSW.FullScanThreshold != nil {
+			hnsw.FullScanThreshold = req.HNSW.FullScanThreshold
+		}
+		if req.HNSW.OnDisk != nil {
+			hnsw.OnDisk = req.HNSW.OnDisk
+		}
+		params.HNSW = &hnsw
+	}
+	if req.Quantization != nil {
+		params.Quantization = req.Quantization
+		if req.Quantization.Type == models.QuantizationDisabled {
+			params.Quantization = nil
+		}
+	}
+	return params
+}
+
+// lookup retorna a base pelo nome
+func (kb *knowledgeBases) lookup(name string) (*KnowledgeBase, bool) {
+	kb.mu.RLock()
+	defer kb.mu.RUnlock()
+	base, ok := kb.byName[name]
+	return base, ok
+}
+
+// add registra uma nova base no fim da lista
+func (kb *knowledgeBases) add(base *KnowledgeBase) {
+	kb.mu.Lock()
+	defer kb.mu.Unlock()
+	kb.list = append(kb.list, base)
+	kb.byName[base.Name] = base
+}
+
+// replace substitui a base de mesmo nome, descartando o embedding da descrição e os clientes dos tenants
+func (kb *knowledgeBases) replace(base *KnowledgeBase) {
+	kb.mu.Lock()
+	for i, existing := range kb.list {
+		if existing.Name == base.Name {
+			kb.list[i] = base
+		}
+	}
+	kb.byName[base.Name] = base
+	delete(kb.embeddings, base.Name)
+	kb.mu.Unlock()
+
+	kb.forgetTenantClients(base.Name)
+}
+
+// remove retira a base da lista, descartando o embedding da descrição e os clientes dos tenants
+func (kb *knowledgeBases) remove(name string) {
+	kb.mu.Lock()
+	list := make([]*KnowledgeBase, 0, len(kb.list))
+	for _, existing := range kb.list {
+		if existing.Name != name {
+			list = append(list, existing)
+		}
+	}
+	kb.list = list
+	delete(kb.byName, name)
+	delete(kb.embeddings, name)
+	kb.mu.Unlock()
+
+	kb.forgetTenantClients(name)
+}
+
+// forgetTenantClients descarta os clientes em cache das coleções dos tenants na base
+func (kb *knowledgeBases) forgetTenantClients(name string) {
+	kb.clientsMu.Lock()
+	defer kb.clientsMu.Unlock()
+	for key := range kb.tenantClients {
+		if strings.HasPrefix(key, name+"/") {
+			delete(kb.tenantClients, key)
+		}
+	}
+}
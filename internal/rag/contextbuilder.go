@@ -0,0 +1,121 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/pkoukk/tiktoken-go"
+)
+
+const (
+	// defaultContextTokenBudget é o orçamento padrão de tokens para o contexto enviado ao modelo
+	defaultContextTokenBudget = 2500
+	// minContextScore é o score vetorial mínimo para um documento entrar no contexto
+	minContextScore = 0.75
+	// minTrimmedTokens evita incluir fragmentos pequenos demais para serem úteis
+	minTrimmedTokens = 32
+
+	dropReasonBelowScore = "below_score"
+	dropReasonBudget     = "budget"
+)
+
+// contextBuilder seleciona e recorta os documentos que cabem no orçamento de tokens do contexto
+type contextBuilder struct {
+	encoding *tiktoken.Tiktoken
+}
+
+// newContextBuilder cria um construtor de contexto com o tokenizer do modelo informado.
+// O carregador offline do BPE é configurado por openai.NewClient.
+func newContextBuilder(model string) (*contextBuilder, error) {
+	encoding, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao carregar tokenizer do modelo %s: %w", model, err)
+	}
+
+	return &contextBuilder{encoding: encoding}, nil
+}
+
+// countTokens conta os tokens de um texto
+func (b *contextBuilder) countTokens(text string) int {
+	return len(b.encoding.EncodeOrdinary(text))
+}
+
+// build empacota os documentos, na ordem de ranking, até esgotar o orçamento. O documento que não
+// cabe inteiro é recortado em limites de frase; os demais são descartados. O conteúdo dos documentos
+// originais não é alterado.
+func (b *contextBuilder) build(docs []models.RelevantDocument, budget int) ([]models.RelevantDocument, *models.ContextReport) {
+	report := &models.ContextReport{
+		TokenBudget: budget,
+		Included:    []models.ContextEntry{},
+		Dropped:     []models.ContextEntry{},
+	}
+
+	var packed []models.RelevantDocument
+	for _, doc := range docs {
+		tokens := b.countTokens(doc.Document.Content)
+		entry := models.ContextEntry{
+			DocumentID: doc.Document.ID,
+			Source:     doc.Document.Source,
+			Tokens:     tokens,
+		}
+
+		if doc.Score < minContextScore {
+			entry.Reason = dropReasonBelowScore
+			report.Dropped = append(report.Dropped, entry)
+			continue
+		}
+
+		remaining := budget - report.UsedTokens
+		if tokens > remaining {
+			if remaining < minTrimmedTokens {
+				entry.Reason = dropReasonBudget
+				report.Dropped = append(report.Dropped, entry)
+				continue
+			}
+
+			doc.Document.Content = b.trim(doc.Document.Content, remaining)
+			entry.Tokens = b.countTokens(doc.Document.Content)
+			entry.Truncated = true
+		}
+
+		report.UsedTokens += entry.Tokens
+		report.Included = append(report.Included, entry)
+		packed = append(packed, doc)
+	}
+
+	return packed, report
+}
+
+// trim reduz o texto ao maior prefixo de frases completas que cabe em maxTokens. Se nem a primeira
+// frase couber, corta diretamente nos tokens.
+func (b *contextBuilder) trim(text string, maxTokens int) string {
+	var kept []string
+	used := 0
+	for _, sentence := range splitSentences(text) {
+		tokens := b.countTokens(sentence) + 1 // separador entre frases
+		if used+tokens > maxTokens {
+			break
+		}
+		kept = append(kept, sentence)
+		used += tokens
+	}
+
+	if len(kept) > 0 {
+		return strings.Join(kept, " ")
+	}
+
+	tokens := b.encoding.EncodeOrdinary(text)
+	if len(tokens) > maxTokens {
+		tokens = tokens[:maxTokens]
+	}
+
+	// O corte em tokens pode separar os bytes de um caractere; recua até o último caractere completo
+	trimmed := b.encoding.Decode(tokens)
+	for !utf8.ValidString(trimmed) {
+		_, size := utf8.DecodeLastRuneInString(trimmed)
+		trimmed = trimmed[:len(trimmed)-size]
+	}
+	return trimmed
+}
@@ -0,0 +1,189 @@
+package rag
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+func newTestContextBuilder(t *testing.T) *contextBuilder {
+	t.Helper()
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+
+	builder, err := newContextBuilder("gpt-3.5-turbo")
+	if err != nil {
+		t.Fatalf("newContextBuilder() error = %v", err)
+	}
+	return builder
+}
+
+func contextDoc(id string, score float32, content string) models.RelevantDocument {
+	return models.RelevantDocument{
+		Document: models.Document{ID: id, Source: id + ".txt", Content: content},
+		Score:    score,
+	}
+}
+
+func entryIDs(entries []models.ContextEntry) []string {
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.DocumentID+":"+entry.Reason)
+	}
+	return ids
+}
+
+func TestContextBuilderBuild(t *testing.T) {
+	b := newTestContextBuilder(t)
+
+	short := "O brigadeiro leva leite condensado, chocolate e manteiga."
+	sentence := "Mexa a mistura em fogo baixo até desgrudar do fundo da panela."
+	long := strings.Repeat(sentence+" ", 20)
+	shortTokens := b.countTokens(short)
+	longTokens := b.countTokens(long)
+
+	tests := []struct {
+		name          string
+		docs          []models.RelevantDocument
+		budget        int
+		wantPacked    []string
+		wantIncluded  []string
+		wantDropped   []string
+		wantTruncated []bool
+		wantUsed      int
+	}{
+		{
+			name:          "todos cabem no orçamento",
+			docs:          []models.RelevantDocument{contextDoc("a", 0.9, short), contextDoc("b", 0.8, short)},
+			budget:        2 * shortTokens,
+			wantPacked:    []string{"a", "b"},
+			wantIncluded:  []string{"a:", "b:"},
+			wantDropped:   []string{},
+			wantTruncated: []bool{false, false},
+			wantUsed:      2 * shortTokens,
+		},
+		{
+			name:          "score abaixo do mínimo é descartado",
+			docs:          []models.RelevantDocument{contextDoc("a", 0.9, short), contextDoc("b", minContextScore-0.01, short)},
+			budget:        defaultContextTokenBudget,
+			wantPacked:    []string{"a"},
+			wantIncluded:  []string{"a:"},
+			wantDropped:   []string{"b:" + dropReasonBelowScore},
+			wantTruncated: []bool{false},
+			wantUsed:      shortTokens,
+		},
+		{
+			name:          "documento que não cabe é recortado em frases",
+			docs:          []models.RelevantDocument{contextDoc("a", 0.9, short), contextDoc("b", 0.9, long)},
+			budget:        shortTokens + longTokens/2,
+			wantPacked:    []string{"a", "b"},
+			wantIncluded:  []string{"a:", "b:"},
+			wantDropped:   []string{},
+			wantTruncated: []bool{false, true},
+		},
+		{
+			name:          "sobra menor que o mínimo descarta o documento",
+			docs:          []models.RelevantDocument{contextDoc("a", 0.9, short), contextDoc("b", 0.9, long)},
+			budget:        shortTokens + minTrimmedTokens - 1,
+			wantPacked:    []string{"a"},
+			wantIncluded:  []string{"a:"},
+			wantDropped:   []string{"b:" + dropReasonBudget},
+			wantTruncated: []bool{false},
+			wantUsed:      shortTokens,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packed, report := b.build(tt.docs, tt.budget)
+
+			if got := documentIDs(packed); !reflect.DeepEqual(got, tt.wantPacked) {
+				t.Fatalf("build() packed = %v, want %v", got, tt.wantPacked)
+			}
+			if got := entryIDs(report.Included); !reflect.DeepEqual(got, tt.wantIncluded) {
+				t.Errorf("build() included = %v, want %v", got, tt.wantIncluded)
+			}
+			if got := entryIDs(report.Dropped); !reflect.DeepEqual(got, tt.wantDropped) {
+				t.Errorf("build() dropped = %v, want %v", got, tt.wantDropped)
+			}
+
+			used := 0
+			for i, entry := range report.Included {
+				if entry.Truncated != tt.wantTruncated[i] {
+					t.Errorf("%s: truncated = %v, want %v", entry.DocumentID, entry.Truncated, tt.wantTruncated[i])
+				}
+				if tokens := b.countTokens(packed[i].Document.Content); tokens != entry.Tokens {
+					t.Errorf("%s: tokens = %d, conteúdo tem %d", entry.DocumentID, entry.Tokens, tokens)
+				}
+				used += entry.Tokens
+			}
+			if report.UsedTokens != used || report.UsedTokens > tt.budget {
+				t.Errorf("build() used = %d (soma %d), orçamento %d", report.UsedTokens, used, tt.budget)
+			}
+			if tt.wantUsed != 0 && report.UsedTokens != tt.wantUsed {
+				t.Errorf("build() used = %d, want %d", report.UsedTokens, tt.wantUsed)
+			}
+		})
+	}
+}
+
+func TestContextBuilderBuildKeepsInput(t *testing.T) {
+	b := newTestContextBuilder(t)
+	long := strings.Repeat("Mexa a mistura em fogo baixo até desgrudar do fundo da panela. ", 20)
+	docs := []models.RelevantDocument{contextDoc("a", 0.9, long)}
+
+	packed, _ := b.build(docs, b.countTokens(long)/2)
+
+	if docs[0].Document.Content != long {
+		t.Error("build() alterou o conteúdo do documento original")
+	}
+	if !strings.HasSuffix(packed[0].Document.Content, ".") {
+		t.Errorf("recorte deveria terminar em fim de frase: %q", packed[0].Document.Content)
+	}
+}
+
+func TestContextBuilderTrim(t *testing.T) {
+	b := newTestContextBuilder(t)
+	text := "Primeira frase curta. Segunda frase um pouco mais longa que a primeira. Terceira frase."
+
+	tests := []struct {
+		name      string
+		maxTokens int
+		want      string
+	}{
+		{name: "cabe a primeira frase", maxTokens: b.countTokens("Primeira frase curta.") + 1, want: "Primeira frase curta."},
+		{name: "cabe o texto inteiro", maxTokens: b.countTokens(text) + 3, want: text},
+		{name: "nenhuma frase cabe", maxTokens: 2, want: b.encoding.Decode(b.encoding.EncodeOrdinary(text)[:2])},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.trim(text, tt.maxTokens); got != tt.want {
+				t.Errorf("trim() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextBuilderTrimKeepsValidUTF8(t *testing.T) {
+	b := newTestContextBuilder(t)
+	text := strings.Repeat("🍫🍮ção", 10)
+	tokens := len(b.encoding.EncodeOrdinary(text))
+
+	for maxTokens := 1; maxTokens < tokens; maxTokens++ {
+		got := b.trim(text, maxTokens)
+		if !utf8.ValidString(got) {
+			t.Fatalf("trim(%d) = %q, não é UTF-8 válido", maxTokens, got)
+		}
+		if !strings.HasPrefix(text, got) {
+			t.Fatalf("trim(%d) = %q, não é prefixo do texto", maxTokens, got)
+		}
+		if b.countTokens(got) > maxTokens {
+			t.Fatalf("trim(%d) = %q, usa %d tokens", maxTokens, got, b.countTokens(got))
+		}
+	}
+}
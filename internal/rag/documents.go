@@ -0,0 +1,223 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
+)
+
+// isPointID indica se o ID é válido para um ponto do Qdrant: um UUID ou um inteiro sem sinal
+func isPointID(id models.PointID) bool {
+	if id.IsNumeric() {
+		return true
+	}
+	_, err := uuid.Parse(string(id))
+	return err == nil
+}
+
+// validateDocumentID recusa IDs de documento que não podem existir na base
+func validateDocumentID(docID string) error {
+	if !isPointID(models.PointID(docID)) {
+		return fmt.Errorf("%w: ID de documento '%s' não é um UUID nem um inteiro sem sinal", ErrInvalidRequest, docID)
+	}
+	return nil
+}
+
+// UpdateDocument altera um documento da base. Mudanças de fonte e metadados atualizam apenas o
+// payload; um novo conteúdo gera outro embedding e conta na cota de documentos do tenant. O conteúdo
+// de documentos com trechos (metadata.parent_id) não pode ser alterado, pois os trechos ficariam
+// desatualizados: remova o documento e indexe-o novamente.
+func (s *Service) UpdateDocument(ctx context.Context, collection string, docID string, req models.UpdateDocumentRequest) (*models.UpdateDocumentResponse, error) {
+	if err := validateDocumentID(docID); err != nil {
+		return nil, err
+	}
+	if req.Content == nil && req.Source == nil && len(req.Metadata) == 0 && len(req.RemoveMetadata) == 0 {
+		return nil, fmt.Errorf("%w: nenhuma alteração informada", ErrInvalidRequest)
+	}
+
+	base, err := s.base(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Infof("Atualizando documento '%s' na base '%s'", docID, base.Name)
+
+	doc, err := base.Client.GetDocument(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Content != nil && *req.Content != doc.Content {
+		chunks, err := base.Client.CountChunks(ctx, docID)
+		if err != nil {
+			return nil, err
+		}
+		if chunks > 0 {
+			return nil, fmt.Errorf("%w: o documento tem %d trechos que ficariam desatualizados; remova-o e indexe o novo conteúdo", ErrInvalidRequest, chunks)
+		}
+
+		doc.Content = *req.Content
+		if req.Source != nil {
+			doc.Source = *req.Source
+		}
+		for _, key := range req.RemoveMetadata {
+			delete(doc.Metadata, key)
+		}
+		for key, value := range req.Metadata {
+			doc.Metadata[key] = value
+		}
+
+		if err := s.reindexDocument(ctx, base, doc); err != nil {
+			return nil, err
+		}
+		return &models.UpdateDocumentResponse{Document: doc, Reembedded: true}, nil
+	}
+
+	payload, removed := payloadChanges(req.Source, req.Metadata, req.RemoveMetadata)
+	if len(payload) > 0 {
+		if err := base.Client.SetPayload(ctx, docID, payload); err != nil {
+			s.logger.WithError(err).Errorf("Erro ao atualizar documento %s", docID)
+			return nil, err
+		}
+	}
+	if len(removed) > 0 {
+		if err := base.Client.DeletePayload(ctx, docID, removed); err != nil {
+			s.logger.WithError(err).Errorf("Erro ao atualizar documento %s", docID)
+			return nil, err
+		}
+	}
+
+	doc, err = base.Client.GetDocument(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.UpdateDocumentResponse{Document: doc}, nil
+}
+
+// reindexDocument gera o embedding do novo conteúdo e substitui o ponto do documento
+func (s *Service) reindexDocument(ctx context.Context, base *KnowledgeBase, doc models.Document) error {
+	t := tenant.FromContext(ctx)
+	if err := s.config.Tenants.ConsumeDocuments(t, 1); err != nil {
+		s.logger.WithField("tenant", t.ID).Warn("Cota diária de documentos excedida")
+		return err
+	}
+
+	embedding, err := s.openaiClient.GenerateEmbedding(ctx, doc.Content)
+	if err == nil {
+		err = base.Client.IndexDocument(ctx, doc, embedding)
+	}
+	if err != nil {
+		s.config.Tenants.ReleaseDocuments(t, 1)
+		s.logger.WithError(err).Errorf("Erro ao reindexar documento %s", doc.ID)
+		return fmt.Errorf("erro ao reindexar documento: %w", err)
+	}
+	return nil
+}
+
+// DeleteDocument remove o documento da base junto com os trechos que apontam para ele
+// (metadata.parent_id), retornando quantos pontos foram removidos
+func (s *Service) DeleteDocument(ctx context.Context, collection string, docID string) (int, error) {
+	if err := validateDocumentID(docID); err != nil {
+		return 0, err
+	}
+	base, err := s.base(ctx, collection)
+	if err != nil {
+		return 0, err
+	}
+	s.logger.Infof("Removendo documento '%s' da base '%s'", docID, base.Name)
+	return base.Client.DeleteDocument(ctx, docID)
+}
+
+// payloadChanges converte as alterações de fonte e metadados nos campos do payload a gravar e a
+// remover; uma chave gravada e removida na mesma requisição é mantida com o novo valor
+func payloadChanges(source *string, metadata map[string]string, removeMetadata []string) (map[string]interface{}, []string) {
+	payload := make(map[string]interface{})
+	if source != nil {
+		payload["source"] = *source
+	}
+	for key, value := range metadata {
+		payload["metadata_"+key] = value
+	}
+
+	var removed []string
+	for _, key := range removeMetadata {
+		if _, ok := metadata[key]; !ok {
+			removed = append(removed, "metadata_"+key)
+		}
+	}
+	return payload, removed
+}
+
+// validateBulkFilter recusa filtros vazios, que selecionariam a base inteira
+func validateBulkFilter(filter models.SearchFilter) error {
+	if filter.CreatedAfter == nil && filter.CreatedBefore == nil && filter.Source == "" && len(filter.Metadata) == 0 {
+		return fmt.Errorf("%w: informe ao menos um critério no filtro", ErrInvalidRequest)
+	}
+	return nil
+}
+
+// DeleteByFilter remove todos os documentos da base que atendem ao filtro. Em dry run, apenas conta
+// os documentos que seriam removidos.
+func (s *Service) DeleteByFilter(ctx context.Context, req models.DeleteByFilterRequest) (*models.BulkOperationResponse, error) {
+	if err := validateBulkFilter(req.Filter); err != nil {
+		return nil, err
+	}
+
+	base, err := s.base(ctx, req.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DryRun {
+		matched, err := base.Client.CountByFilter(ctx, req.Filter)
+		if err != nil {
+			return nil, err
+		}
+		s.logger.Infof("Dry run: %d documentos seriam removidos da base '%s'", matched, base.Name)
+		return &models.BulkOperationResponse{Matched: matched, DryRun: true}, nil
+	}
+
+	matched, err := base.Client.DeleteByFilter(ctx, req.Filter)
+	if err != nil {
+		s.logger.WithError(err).Error("Erro ao remover documentos por filtro")
+		return nil, err
+	}
+	s.logger.Infof("%d documentos removidos por filtro da base '%s'", matched, base.Name)
+	return &models.BulkOperationResponse{Matched: matched}, nil
+}
+
+// UpdateByFilter altera a fonte e os metadados de todos os documentos da base que atendem ao filtro,
+// sem gerar embeddings. Em dry run, apenas conta os documentos que seriam alterados.
+func (s *Service) UpdateByFilter(ctx context.Context, req models.UpdateByFilterRequest) (*models.BulkOperationResponse, error) {
+	if err := validateBulkFilter(req.Filter); err != nil {
+		return nil, err
+	}
+	payload, removed := payloadChanges(req.Source, req.Metadata, req.RemoveMetadata)
+	if len(payload) == 0 && len(removed) == 0 {
+		return nil, fmt.Errorf("%w: nenhuma alteração informada", ErrInvalidRequest)
+	}
+
+	base, err := s.base(ctx, req.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DryRun {
+		matched, err := base.Client.CountByFilter(ctx, req.Filter)
+		if err != nil {
+			return nil, err
+		}
+		s.logger.Infof("Dry run: %d documentos seriam alterados na base '%s'", matched, base.Name)
+		return &models.BulkOperationResponse{Matched: matched, DryRun: true}, nil
+	}
+
+	matched, err := base.Client.UpdatePayloadByFilter(ctx, req.Filter, payload, removed)
+	if err != nil {
+		s.logger.WithError(err).Error("Erro ao alterar documentos por filtro")
+		return nil, err
+	}
+	s.logger.Infof("%d documentos alterados por filtro na base '%s'", matched, base.Name)
+	return &models.BulkOperationResponse{Matched: matched}, nil
+}
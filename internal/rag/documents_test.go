@@ -0,0 +1,117 @@
+package rag
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+func TestPayloadChanges(t *testing.T) {
+	source := "receitas.txt"
+
+	tests := []struct {
+		name        string
+		source      *string
+		metadata    map[string]string
+		remove      []string
+		wantPayload map[string]interface{}
+		wantRemoved []string
+	}{
+		{
+			name:        "sem alterações",
+			wantPayload: map[string]interface{}{},
+		},
+		{
+			name:        "fonte e metadados viram campos do payload",
+			source:      &source,
+			metadata:    map[string]string{"category": "doces"},
+			wantPayload: map[string]interface{}{"source": "receitas.txt", "metadata_category": "doces"},
+		},
+		{
+			name:        "remoção usa o prefixo dos metadados",
+			remove:      []string{"draft", "autor"},
+			wantPayload: map[string]interface{}{},
+			wantRemoved: []string{"metadata_autor", "metadata_draft"},
+		},
+		{
+			name:        "chave gravada e removida mantém o novo valor",
+			metadata:    map[string]string{"draft": "nao"},
+			remove:      []string{"draft", "autor"},
+			wantPayload: map[string]interface{}{"metadata_draft": "nao"},
+			wantRemoved: []string{"metadata_autor"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, removed := payloadChanges(tt.source, tt.metadata, tt.remove)
+			sort.Strings(removed)
+
+			if !reflect.DeepEqual(payload, tt.wantPayload) {
+				t.Errorf("payloadChanges() payload = %v, want %v", payload, tt.wantPayload)
+			}
+			if !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("payloadChanges() removed = %v, want %v", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestValidateDocumentID(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		wantErr bool
+	}{
+		{name: "UUID", id: "5c56c793-69f3-4fbf-87e6-c4bf54c28c26"},
+		{name: "inteiro sem sinal", id: "42"},
+		{name: "vazio", id: "", wantErr: true},
+		{name: "inteiro negativo", id: "-1", wantErr: true},
+		{name: "texto livre", id: "receita-1", wantErr: true},
+		{name: "caminho", id: "../collections", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDocumentID(tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateDocumentID(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("validateDocumentID(%q) error = %v, want ErrInvalidRequest", tt.id, err)
+			}
+		})
+	}
+}
+
+func TestValidateBulkFilter(t *testing.T) {
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		filter  models.SearchFilter
+		wantErr bool
+	}{
+		{name: "filtro vazio", filter: models.SearchFilter{}, wantErr: true},
+		{name: "metadados vazios não contam", filter: models.SearchFilter{Metadata: map[string]string{}}, wantErr: true},
+		{name: "por fonte", filter: models.SearchFilter{Source: "receitas.txt"}},
+		{name: "por data", filter: models.SearchFilter{CreatedAfter: &after}},
+		{name: "por metadados", filter: models.SearchFilter{Metadata: map[string]string{"draft": "sim"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBulkFilter(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateBulkFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("validateBulkFilter() error = %v, want ErrInvalidRequest", err)
+			}
+		})
+	}
+}
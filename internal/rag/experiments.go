@@ -0,0 +1,102 @@
+package rag
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcopollivier/rag-go-ex01/internal/experiment"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
+	"github.com/sirupsen/logrus"
+)
+
+// assignExperiment atribui a variante do experimento habilitado pela chave do usuário e aplica
+// seus parâmetros de recuperação e template aos campos que a requisição não definiu.
+// Sem chave, a consulta recebe uma variante aleatória.
+func (s *Service) assignExperiment(req *models.QueryRequest) *experiment.Assignment {
+	userKey := req.UserKey
+	if userKey == "" {
+		userKey = uuid.New().String()
+	}
+
+	assignment := s.config.Experiments.Assign(userKey)
+	if assignment == nil {
+		return nil
+	}
+
+	variant := assignment.Variant
+	if req.PromptTemplate == "" {
+		req.PromptTemplate = variant.PromptTemplate
+	}
+	if req.TopK == 0 {
+		req.TopK = variant.TopK
+	}
+	if req.Threshold == 0 {
+		req.Threshold = variant.Threshold
+	}
+
+	s.logger.Infof("Consulta atribuída à variante '%s' do experimento '%s'", variant.ID, assignment.ExperimentID)
+	return assignment
+}
+
+// recordExposure registra a latência e o consumo de tokens da consulta na variante que a serviu
+func (s *Service) recordExposure(ctx context.Context, queryID string, assignment *experiment.Assignment, latency time.Duration, usage *models.Usage) {
+	if assignment == nil {
+		return
+	}
+
+	var tokens experiment.Usage
+	if usage != nil {
+		tokens = experiment.Usage{
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
+		}
+	}
+	s.config.Experiments.RecordExposure(tenant.IDFromContext(ctx), queryID, assignment, latency, tokens)
+
+	s.logger.WithFields(logrus.Fields{
+		"query_id":          queryID,
+		"experiment_id":     assignment.ExperimentID,
+		"variant_id":        assignment.Variant.ID,
+		"latency_ms":        latency.Milliseconds(),
+		"prompt_tokens":     tokens.PromptTokens,
+		"completion_tokens": tokens.CompletionTokens,
+		"total_tokens":      tokens.TotalTokens,
+	}).Info("Exposição a experimento")
+}
+
+// RecordFeedback registra a avaliação do usuário sobre uma resposta, atribuindo-a à variante de
+// experimento que serviu a consulta quando houver. Feedback repetido para a mesma consulta é ignorado
+// e retorna experiment.ErrFeedbackRecorded.
+func (s *Service) RecordFeedback(ctx context.Context, req models.FeedbackRequest) (*models.FeedbackResponse, error) {
+	response := &models.FeedbackResponse{QueryID: req.QueryID}
+	fields := logrus.Fields{
+		"query_id": req.QueryID,
+		"rating":   req.Rating,
+		"comment":  req.Comment,
+	}
+
+	assignment, err := s.config.Experiments.RecordFeedback(tenant.IDFromContext(ctx), req.QueryID, req.Rating == models.FeedbackPositive)
+	if err != nil {
+		s.logger.WithFields(fields).Warn("Feedback repetido ignorado")
+		return nil, err
+	}
+	if assignment != nil {
+		response.Experiment = &models.ExperimentAssignment{
+			ExperimentID: assignment.ExperimentID,
+			VariantID:    assignment.Variant.ID,
+		}
+		fields["experiment_id"] = assignment.ExperimentID
+		fields["variant_id"] = assignment.Variant.ID
+	}
+
+	s.logger.WithFields(fields).Info("Feedback recebido")
+	return response, nil
+}
+
+// ExperimentResults retorna as métricas acumuladas por variante de cada experimento no tenant da requisição
+func (s *Service) ExperimentResults(ctx context.Context) []experiment.Result {
+	return s.config.Experiments.Results(tenant.IDFromContext(ctx))
+}
@@ -0,0 +1,47 @@
+package rag
+
+import (
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/ranking"
+)
+
+// rrfK é a constante de suavização do Reciprocal Rank Fusion
+const rrfK = 60
+
+// fuseResults combina os resultados de várias buscas com Reciprocal Rank Fusion.
+// Cada documento mantém o maior score vetorial obtido e recebe o score fundido.
+func fuseResults(resultSets [][]models.RelevantDocument) []models.RelevantDocument {
+	fused := make(map[string]*models.RelevantDocument)
+	var order []string
+
+	for _, results := range resultSets {
+		for rank, doc := range results {
+			contribution := float32(1) / float32(rrfK+rank+1)
+
+			existing, ok := fused[doc.Document.ID]
+			if !ok {
+				doc := doc
+				score := contribution
+				doc.FusedScore = &score
+				fused[doc.Document.ID] = &doc
+				order = append(order, doc.Document.ID)
+				continue
+			}
+
+			*existing.FusedScore += contribution
+			if doc.Score > existing.Score {
+				existing.Score = doc.Score
+				existing.Vector = doc.Vector
+			}
+		}
+	}
+
+	merged := make([]models.RelevantDocument, 0, len(order))
+	for _, id := range order {
+		merged = append(merged, *fused[id])
+	}
+
+	ranking.Sort(merged)
+
+	return merged
+}
@@ -0,0 +1,82 @@
+package rag
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+func TestFuseResults(t *testing.T) {
+	rrf := func(ranks ...int) float32 {
+		var score float32
+		for _, rank := range ranks {
+			score += float32(1) / float32(rrfK+rank)
+		}
+		return score
+	}
+
+	tests := []struct {
+		name       string
+		resultSets [][]models.RelevantDocument
+		wantIDs    []string
+		wantFused  []float32
+		wantScores []float32
+	}{
+		{
+			name:       "sem resultados",
+			resultSets: nil,
+			wantIDs:    []string{},
+			wantFused:  []float32{},
+			wantScores: []float32{},
+		},
+		{
+			name: "uma busca mantém a ordem",
+			resultSets: [][]models.RelevantDocument{
+				{relevantDoc("a", 0.9), relevantDoc("b", 0.8)},
+			},
+			wantIDs:    []string{"a", "b"},
+			wantFused:  []float32{rrf(1), rrf(2)},
+			wantScores: []float32{0.9, 0.8},
+		},
+		{
+			name: "documento presente em várias buscas sobe no ranking",
+			resultSets: [][]models.RelevantDocument{
+				{relevantDoc("a", 0.9), relevantDoc("b", 0.8)},
+				{relevantDoc("c", 0.95), relevantDoc("b", 0.85)},
+			},
+			wantIDs:    []string{"b", "a", "c"},
+			wantFused:  []float32{rrf(2, 2), rrf(1), rrf(1)},
+			wantScores: []float32{0.85, 0.9, 0.95},
+		},
+		{
+			name: "empate preserva a ordem de aparição",
+			resultSets: [][]models.RelevantDocument{
+				{relevantDoc("a", 0.7)},
+				{relevantDoc("b", 0.9)},
+			},
+			wantIDs:    []string{"a", "b"},
+			wantFused:  []float32{rrf(1), rrf(1)},
+			wantScores: []float32{0.7, 0.9},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fused := fuseResults(tt.resultSets)
+
+			if got := documentIDs(fused); !reflect.DeepEqual(got, tt.wantIDs) {
+				t.Fatalf("fuseResults() ids = %v, want %v", got, tt.wantIDs)
+			}
+			for i, doc := range fused {
+				if doc.FusedScore == nil || math.Abs(float64(*doc.FusedScore-tt.wantFused[i])) > 1e-6 {
+					t.Errorf("%s: fused score = %v, want %v", doc.Document.ID, doc.FusedScore, tt.wantFused[i])
+				}
+				if doc.Score != tt.wantScores[i] {
+					t.Errorf("%s: score = %v, want %v (maior score vetorial)", doc.Document.ID, doc.Score, tt.wantScores[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package rag
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/rerank"
+)
+
+// maxHighlights é o número máximo de trechos destacados por documento
+const maxHighlights = 3
+
+// highlight seleciona as frases do conteúdo com mais termos da query, marcando os termos com <em>
+// sobre o texto escapado para HTML
+func highlight(query, content string) []string {
+	queryTerms := rerank.Terms(query)
+	if len(queryTerms) == 0 {
+		return nil
+	}
+
+	type scoredSentence struct {
+		index   int
+		text    string
+		matches int
+	}
+
+	var candidates []scoredSentence
+	for i, sentence := range splitSentences(content) {
+		matches := 0
+		for term := range rerank.Terms(sentence) {
+			if _, ok := queryTerms[term]; ok {
+				matches++
+			}
+		}
+		if matches > 0 {
+			candidates = append(candidates, scoredSentence{index: i, text: sentence, matches: matches})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].matches > candidates[j].matches
+	})
+	if len(candidates) > maxHighlights {
+		candidates = candidates[:maxHighlights]
+	}
+
+	// Manter a ordem original das frases no documento
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].index < candidates[j].index
+	})
+
+	highlights := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		highlights = append(highlights, markTerms(candidate.text, queryTerms))
+	}
+	return highlights
+}
+
+// markTerms envolve com <em> as palavras do texto que pertencem ao conjunto de termos. O restante do
+// texto é escapado, para que marcação presente nos documentos não seja interpretada como HTML.
+func markTerms(text string, terms map[string]struct{}) string {
+	var b strings.Builder
+	var word []rune
+
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		escaped := html.EscapeString(string(word))
+		if _, ok := terms[strings.ToLower(string(word))]; ok {
+			b.WriteString("<em>" + escaped + "</em>")
+		} else {
+			b.WriteString(escaped)
+		}
+		word = word[:0]
+	}
+
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		b.WriteString(html.EscapeString(string(r)))
+	}
+	flush()
+
+	return b.String()
+}
+
+// splitSentences divide um texto em frases, usando pontuação final e quebras de linha como delimitadores
+func splitSentences(text string) []string {
+	var sentences []string
+	var current strings.Builder
+
+	emit := func() {
+		if sentence := strings.TrimSpace(current.String()); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		if r == '\n' {
+			emit()
+			continue
+		}
+		current.WriteRune(r)
+		if (r == '.' || r == '!' || r == '?') && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+			emit()
+		}
+	}
+	emit()
+
+	return sentences
+}
@@ -0,0 +1,63 @@
+package rag
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/rerank"
+)
+
+func TestMarkTerms(t *testing.T) {
+	terms := rerank.Terms("brigadeiro chocolate")
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "marca os termos sem diferenciar maiúsculas", text: "Brigadeiro de CHOCOLATE.", want: "<em>Brigadeiro</em> de <em>CHOCOLATE</em>."},
+		{name: "tags do documento são escapadas", text: "<script>brigadeiro</script>", want: "&lt;script&gt;<em>brigadeiro</em>&lt;/script&gt;"},
+		{name: "aspas e e-comercial são escapados", text: `Leite & "chocolate"`, want: "Leite &amp; &#34;<em>chocolate</em>&#34;"},
+		{name: "marcação <em> do documento não é preservada", text: "<em>bolo</em>", want: "&lt;em&gt;bolo&lt;/em&gt;"},
+		{name: "termo dentro de outra palavra não é marcado", text: "chocolateria", want: "chocolateria"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markTerms(tt.text, terms); got != tt.want {
+				t.Errorf("markTerms(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	content := "Bolo de cenoura. Brigadeiro leva chocolate. Asse por 40 minutos.\n" +
+		"Sirva o brigadeiro frio. Chocolate <b>meio amargo</b> e brigadeiro gourmet. Enrole o brigadeiro."
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "frases com mais termos, na ordem do documento",
+			query: "brigadeiro de chocolate",
+			want: []string{
+				"<em>Brigadeiro</em> leva <em>chocolate</em>.",
+				"Sirva o <em>brigadeiro</em> frio.",
+				"<em>Chocolate</em> &lt;b&gt;meio amargo&lt;/b&gt; e <em>brigadeiro</em> gourmet.",
+			},
+		},
+		{name: "nenhuma frase com os termos", query: "pudim", want: []string{}},
+		{name: "query sem termos", query: "o de a", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlight(tt.query, content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("highlight(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
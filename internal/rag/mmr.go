@@ -0,0 +1,76 @@
+package rag
+
+import (
+	"math"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/ranking"
+)
+
+const (
+	// defaultMMRLambda equilibra relevância (1.0) e diversidade (0.0)
+	defaultMMRLambda = 0.5
+	// defaultMMRCandidates é o número de candidatos buscados antes da diversificação
+	defaultMMRCandidates = 20
+)
+
+// selectMMR escolhe até k documentos maximizando relevância e penalizando redundância
+// (Maximal Marginal Relevance). A relevância é o score de ranking compartilhado com a fusão, a
+// recência e o rerank, normalizado entre os candidatos para ficar na escala da similaridade.
+// Documentos sem vetor são tratados como sem similaridade.
+func selectMMR(docs []models.RelevantDocument, k int, lambda float32) []models.RelevantDocument {
+	if k >= len(docs) {
+		k = len(docs)
+	}
+
+	relevance := ranking.Scores(docs)
+	remaining := make([]int, len(docs))
+	for i := range remaining {
+		remaining[i] = i
+	}
+	selected := make([]models.RelevantDocument, 0, k)
+
+	for len(selected) < k {
+		bestIdx := 0
+		bestScore := float32(math.Inf(-1))
+
+		for i, candidate := range remaining {
+			maxSim := float32(0)
+			for _, chosen := range selected {
+				if sim := cosineSimilarity(docs[candidate].Vector, chosen.Vector); sim > maxSim {
+					maxSim = sim
+				}
+			}
+
+			score := lambda*relevance[candidate] - (1-lambda)*maxSim
+			if score > bestScore {
+				bestScore = score
+				bestIdx = i
+			}
+		}
+
+		selected = append(selected, docs[remaining[bestIdx]])
+		remaining = append(remaining[:bestIdx], remaining[bestIdx+1:]...)
+	}
+
+	return selected
+}
+
+// cosineSimilarity calcula a similaridade de cosseno entre dois vetores
+func cosineSimilarity(a, b []float32) float32 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}
@@ -0,0 +1,143 @@
+package rag
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+func relevantDoc(id string, score float32, vector ...float32) models.RelevantDocument {
+	return models.RelevantDocument{
+		Document: models.Document{ID: id},
+		Score:    score,
+		Vector:   vector,
+	}
+}
+
+func documentIDs(docs []models.RelevantDocument) []string {
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.Document.ID)
+	}
+	return ids
+}
+
+func TestSelectMMR(t *testing.T) {
+	rerankScore := float32(0.99)
+	reranked := relevantDoc("c", 0.5, 0, 1)
+	reranked.RerankScore = &rerankScore
+
+	tests := []struct {
+		name   string
+		docs   []models.RelevantDocument
+		k      int
+		lambda float32
+		want   []string
+	}{
+		{
+			name:   "lista vazia",
+			docs:   nil,
+			k:      3,
+			lambda: 0.5,
+			want:   []string{},
+		},
+		{
+			name:   "k maior que a lista retorna todos",
+			docs:   []models.RelevantDocument{relevantDoc("a", 0.9, 1, 0), relevantDoc("b", 0.8, 0, 1)},
+			k:      5,
+			lambda: 0.5,
+			want:   []string{"a", "b"},
+		},
+		{
+			name: "lambda 1 ordena apenas por relevância",
+			docs: []models.RelevantDocument{
+				relevantDoc("a", 0.9, 1, 0),
+				relevantDoc("b", 0.85, 1, 0),
+				relevantDoc("c", 0.7, 0, 1),
+			},
+			k:      2,
+			lambda: 1,
+			want:   []string{"a", "b"},
+		},
+		{
+			name: "documento redundante é trocado por um diverso",
+			docs: []models.RelevantDocument{
+				relevantDoc("a", 0.9, 1, 0),
+				relevantDoc("b", 0.85, 1, 0),
+				relevantDoc("c", 0.7, 0, 1),
+			},
+			k:      2,
+			lambda: 0.5,
+			want:   []string{"a", "c"},
+		},
+		{
+			name: "score de rerank prevalece sobre o vetorial",
+			docs: []models.RelevantDocument{
+				relevantDoc("a", 0.9, 1, 0),
+				reranked,
+			},
+			k:      1,
+			lambda: 1,
+			want:   []string{"c"},
+		},
+		{
+			name: "documentos sem vetor não são penalizados",
+			docs: []models.RelevantDocument{
+				relevantDoc("a", 0.9),
+				relevantDoc("b", 0.8),
+				relevantDoc("c", 0.7),
+			},
+			k:      3,
+			lambda: 0.5,
+			want:   []string{"a", "b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := documentIDs(selectMMR(tt.docs, tt.k, tt.lambda))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectMMR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectMMRKeepsInput(t *testing.T) {
+	docs := []models.RelevantDocument{
+		relevantDoc("a", 0.9, 1, 0),
+		relevantDoc("b", 0.85, 1, 0),
+		relevantDoc("c", 0.7, 0, 1),
+	}
+	selectMMR(docs, 2, 0.5)
+
+	if got := documentIDs(docs); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("selectMMR alterou a entrada: %v", got)
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float32
+	}{
+		{name: "vetores iguais", a: []float32{1, 2, 3}, b: []float32{1, 2, 3}, want: 1},
+		{name: "vetores opostos", a: []float32{1, 0}, b: []float32{-1, 0}, want: -1},
+		{name: "vetores ortogonais", a: []float32{1, 0}, b: []float32{0, 1}, want: 0},
+		{name: "dimensões diferentes", a: []float32{1, 0}, b: []float32{1, 0, 0}, want: 0},
+		{name: "vetor nulo", a: []float32{0, 0}, b: []float32{1, 0}, want: 0},
+		{name: "vetor vazio", a: nil, b: nil, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cosineSimilarity(tt.a, tt.b)
+			if math.Abs(float64(got-tt.want)) > 1e-6 {
+				t.Errorf("cosineSimilarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package rag
+
+import (
+	"math"
+	"time"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/ranking"
+)
+
+const (
+	// defaultRecencyHalfLifeDays é a meia-vida padrão do reforço por recência (um trimestre)
+	defaultRecencyHalfLifeDays = 90
+	// defaultRecencyCandidates é o número de candidatos buscados antes de aplicar a recência
+	defaultRecencyCandidates = 20
+	// maxRecencyShare é a fração da faixa de relevância dos candidatos que a recência pode compensar
+	// com peso 1: a recência desempata e decide quase empates, mas não supera diferenças maiores
+	maxRecencyShare = 0.1
+)
+
+// applyRecencyBoost atribui a cada documento um reforço que decai exponencialmente com a idade,
+// limitado a weight*maxRecencyShare da relevância normalizada, e reordena os candidatos pelo
+// score de ranking compartilhado com o rerank e o MMR
+func applyRecencyBoost(docs []models.RelevantDocument, weight, halfLifeDays float32, now time.Time) []models.RelevantDocument {
+	boosted := make([]models.RelevantDocument, len(docs))
+	copy(boosted, docs)
+
+	for i := range boosted {
+		boost := float32(0)
+		if created := boosted[i].Document.Created; !created.IsZero() {
+			ageDays := now.Sub(created).Hours() / 24
+			if ageDays < 0 {
+				ageDays = 0
+			}
+			boost = weight * maxRecencyShare * float32(math.Pow(0.5, ageDays/float64(halfLifeDays)))
+		}
+		boosted[i].RecencyBoost = &boost
+	}
+
+	ranking.Sort(boosted)
+
+	return boosted
+}
@@ -0,0 +1,140 @@
+package rag
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/rerank"
+)
+
+var recencyNow = time.Date(2025, 8, 10, 12, 0, 0, 0, time.UTC)
+
+func datedDoc(id string, score float32, ageDays int, content string) models.RelevantDocument {
+	doc := relevantDoc(id, score)
+	doc.Document.Content = content
+	if ageDays >= 0 {
+		doc.Document.Created = recencyNow.AddDate(0, 0, -ageDays)
+	}
+	return doc
+}
+
+func withFusedScore(doc models.RelevantDocument, fused float32) models.RelevantDocument {
+	doc.FusedScore = &fused
+	return doc
+}
+
+func TestApplyRecencyBoost(t *testing.T) {
+	tests := []struct {
+		name       string
+		docs       []models.RelevantDocument
+		weight     float32
+		want       []string
+		wantBoosts map[string]float32
+	}{
+		{
+			name:       "reforço cai pela metade a cada meia-vida",
+			docs:       []models.RelevantDocument{datedDoc("a", 0.8, 0, ""), datedDoc("b", 0.7, 90, ""), datedDoc("c", 0.6, 180, "")},
+			weight:     1,
+			want:       []string{"a", "b", "c"},
+			wantBoosts: map[string]float32{"a": maxRecencyShare, "b": maxRecencyShare / 2, "c": maxRecencyShare / 4},
+		},
+		{
+			name:       "documento sem data não recebe reforço",
+			docs:       []models.RelevantDocument{datedDoc("a", 0.8, -1, ""), datedDoc("b", 0.8, 365, "")},
+			weight:     1,
+			want:       []string{"b", "a"},
+			wantBoosts: map[string]float32{"a": 0},
+		},
+		{
+			name:   "recência desempata scores iguais",
+			docs:   []models.RelevantDocument{datedDoc("antigo", 0.8, 365, ""), datedDoc("novo", 0.8, 0, "")},
+			weight: 0.2,
+			want:   []string{"novo", "antigo"},
+		},
+		{
+			name:   "recência decide quase empates",
+			docs:   []models.RelevantDocument{datedDoc("antigo", 0.8, 365, ""), datedDoc("novo", 0.795, 0, ""), datedDoc("c", 0.7, 365, "")},
+			weight: 1,
+			want:   []string{"novo", "antigo", "c"},
+		},
+		{
+			name:   "recência não supera diferença grande de relevância",
+			docs:   []models.RelevantDocument{datedDoc("antigo", 0.9, 365, ""), datedDoc("novo", 0.7, 0, "")},
+			weight: 1,
+			want:   []string{"antigo", "novo"},
+		},
+		{
+			name: "ranking usa o score fundido",
+			docs: []models.RelevantDocument{
+				withFusedScore(datedDoc("vetorial", 0.95, 365, ""), 0.016),
+				withFusedScore(datedDoc("fundido", 0.7, 365, ""), 0.032),
+			},
+			weight: 1,
+			want:   []string{"fundido", "vetorial"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyRecencyBoost(tt.docs, tt.weight, defaultRecencyHalfLifeDays, recencyNow)
+
+			if ids := documentIDs(got); !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("applyRecencyBoost() = %v, want %v", ids, tt.want)
+			}
+			for _, doc := range got {
+				want, ok := tt.wantBoosts[doc.Document.ID]
+				if !ok {
+					continue
+				}
+				if doc.RecencyBoost == nil || math.Abs(float64(*doc.RecencyBoost-want)) > 1e-6 {
+					t.Errorf("%s: RecencyBoost = %v, want %v", doc.Document.ID, doc.RecencyBoost, want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyRecencyBoostKeepsInput(t *testing.T) {
+	docs := []models.RelevantDocument{datedDoc("antigo", 0.8, 365, ""), datedDoc("novo", 0.8, 0, "")}
+	applyRecencyBoost(docs, 1, defaultRecencyHalfLifeDays, recencyNow)
+
+	if got := documentIDs(docs); !reflect.DeepEqual(got, []string{"antigo", "novo"}) {
+		t.Errorf("applyRecencyBoost alterou a entrada: %v", got)
+	}
+	if docs[0].RecencyBoost != nil {
+		t.Error("applyRecencyBoost alterou o reforço do documento original")
+	}
+}
+
+func TestRecencyBreaksRerankTies(t *testing.T) {
+	docs := []models.RelevantDocument{
+		datedDoc("antigo", 0.9, 365, "Brigadeiro de panela com chocolate."),
+		datedDoc("novo", 0.8, 0, "Brigadeiro gourmet com chocolate belga."),
+		datedDoc("outro", 0.85, 0, "Bolo de cenoura."),
+	}
+
+	boosted := applyRecencyBoost(docs, 0.5, defaultRecencyHalfLifeDays, recencyNow)
+	reranked, err := rerank.NewLexicalReranker().Rerank(context.Background(), "brigadeiro chocolate", boosted)
+	if err != nil {
+		t.Fatalf("Rerank() error = %v", err)
+	}
+
+	if got := documentIDs(reranked); !reflect.DeepEqual(got, []string{"novo", "antigo", "outro"}) {
+		t.Errorf("Rerank() após recência = %v, want [novo antigo outro]", got)
+	}
+}
+
+func TestSelectMMRUsesRankingScore(t *testing.T) {
+	docs := []models.RelevantDocument{
+		withFusedScore(relevantDoc("vetorial", 0.95, 1, 0), 0.016),
+		withFusedScore(relevantDoc("fundido", 0.7, 0, 1), 0.032),
+	}
+
+	if got := documentIDs(selectMMR(docs, 1, 1)); !reflect.DeepEqual(got, []string{"fundido"}) {
+		t.Errorf("selectMMR() = %v, want [fundido]", got)
+	}
+}
@@ -0,0 +1,277 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/knowledge"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/openai"
+	"github.com/marcopollivier/rag-go-ex01/internal/qdrant"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
+)
+
+const (
+	// routingMargin inclui, no roteamento por embedding, as bases com similaridade até esta
+	// distância da melhor
+	routingMargin = 0.05
+	// maxRoutedBases limita as bases escolhidas pelo roteamento por embedding
+	maxRoutedBases = 2
+)
+
+// KnowledgeBase associa uma base de conhecimento ao cliente Qdrant da sua coleção
+type KnowledgeBase struct {
+	knowledge.Base
+	Client *qdrant.Client
+}
+
+// knowledgeBases guarda as bases de conhecimento do serviço; a primeira é a padrão
+type knowledgeBases struct {
+	mu     sync.RWMutex
+	list   []*KnowledgeBase
+	byName map[string]*KnowledgeBase
+	// embeddings guarda o embedding da descrição de cada base, calculado sob demanda
+	embeddings map[string][]float32
+
+	// tenantClients guarda os clientes das coleções próprias de cada tenant, por base
+	clientsMu     sync.Mutex
+	tenantClients map[string]*qdrant.Client
+
+	// adminMu serializa a criação, a atualização e a remoção de bases
+	adminMu sync.Mutex
+}
+
+func newKnowledgeBases(bases []KnowledgeBase) (*knowledgeBases, error) {
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("nenhuma base de conhecimento configurada")
+	}
+
+	kb := &knowledgeBases{
+		byName:        make(map[string]*KnowledgeBase),
+		embeddings:    make(map[string][]float32),
+		tenantClients: make(map[string]*qdrant.Client),
+	}
+	for i := range bases {
+		base := &bases[i]
+		if _, ok := kb.byName[base.Name]; ok {
+			return nil, fmt.Errorf("base de conhecimento '%s' duplicada", base.Name)
+		}
+		kb.list = append(kb.list, base)
+		kb.byName[base.Name] = base
+	}
+	return kb, nil
+}
+
+// all retorna as bases na ordem de configuração
+func (kb *knowledgeBases) all() []*KnowledgeBase {
+	kb.mu.RLock()
+	defer kb.mu.RUnlock()
+	return append([]*KnowledgeBase(nil), kb.list...)
+}
+
+// base retorna a base pelo nome; vazio retorna a base padrão
+func (kb *knowledgeBases) base(name string) (*KnowledgeBase, error) {
+	kb.mu.RLock()
+	defer kb.mu.RUnlock()
+
+	if name == "" {
+		return kb.list[0], nil
+	}
+	base, ok := kb.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: base de conhecimento desconhecida: %s", ErrInvalidRequest, name)
+	}
+	return base, nil
+}
+
+// base retorna a base de conhecimento pelo nome (vazio para a padrão), restrita ao tenant da requisição
+func (s *Service) base(ctx context.Context, name string) (*KnowledgeBase, error) {
+	base, err := s.bases.base(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.scoped(ctx, base)
+}
+
+// ListKnowledgeBases retorna as bases de conhecimento configuradas, com as coleções do tenant da requisição
+func (s *Service) ListKnowledgeBases(ctx context.Context) []knowledge.Base {
+	t := tenant.FromContext(ctx)
+
+	var bases []knowledge.Base
+	for _, base := range s.bases.all() {
+		info := base.Base
+		if t != nil && t.Isolation == tenant.IsolationCollection {
+			info.Collection = tenantCollection(base, t)
+		}
+		bases = append(bases, info)
+	}
+	return bases
+}
+
+// requestedBases resolve as bases informadas explicitamente na requisição (nil quando nenhuma foi
+// informada), antes da recuperação, para que nomes inválidos falhem sem custo de busca
+func (s *Service) requestedBases(names []string) ([]*KnowledgeBase, error) {
+	var bases []*KnowledgeBase
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		base, err := s.bases.base(name)
+		if err != nil {
+			return nil, err
+		}
+		bases = append(bases, base)
+	}
+	return bases, nil
+}
+
+// routeQuery escolhe as bases em que a pergunta será buscada: por classificação com o modelo ou
+// pela similaridade entre o embedding da pergunta e o das descrições das bases
+func (s *Service) routeQuery(ctx context.Context, req models.QueryRequest, queryEmbedding []float32) ([]*KnowledgeBase, error) {
+	bases := s.bases.all()
+	if len(bases) == 1 || req.Routing == models.RoutingAll {
+		return bases, nil
+	}
+
+	var selected []*KnowledgeBase
+	var err error
+	switch req.Routing {
+	case models.RoutingLLM:
+		selected, err = s.routeByClassification(ctx, req.Query, bases)
+	default:
+		selected, err = s.routeByEmbedding(ctx, queryEmbedding, bases)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(selected) == 0 {
+		s.logger.Warn("Roteamento não escolheu nenhuma base, buscando em todas")
+		return bases, nil
+	}
+	return selected, nil
+}
+
+// routeByClassification pede ao modelo que escolha as bases pela descrição
+func (s *Service) routeByClassification(ctx context.Context, query string, bases []*KnowledgeBase) ([]*KnowledgeBase, error) {
+	candidates := make([]openai.RouteCandidate, 0, len(bases))
+	for _, base := range bases {
+		candidates = append(candidates, openai.RouteCandidate{Name: base.Name, Description: base.Description})
+	}
+
+	names, err := s.openaiClient.ClassifyQuery(ctx, query, candidates)
+	if err != nil {
+		s.logger.WithError(err).Error("Erro ao rotear query")
+		return nil, fmt.Errorf("erro ao rotear query: %w", err)
+	}
+
+	return s.requestedBases(names)
+}
+
+// routeByEmbedding escolhe a base cuja descrição é mais similar à pergunta e as que ficam a até
+// routingMargin dela
+func (s *Service) routeByEmbedding(ctx context.Context, queryEmbedding []float32, bases []*KnowledgeBase) ([]*KnowledgeBase, error) {
+	embeddings, err := s.descriptionEmbeddings(ctx, bases)
+	if err != nil {
+		return nil, err
+	}
+
+	type scoredBase struct {
+		base  *KnowledgeBase
+		score float32
+	}
+	scored := make([]scoredBase, 0, len(bases))
+	for i, base := range bases {
+		scored = append(scored, scoredBase{base: base, score: cosineSimilarity(queryEmbedding, embeddings[i])})
+	}
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].score > scored[j].score })
+
+	var selected []*KnowledgeBase
+	for _, candidate := range scored {
+		if len(selected) == maxRoutedBases || candidate.score < scored[0].score-routingMargin {
+			break
+		}
+		s.logger.Debugf("Base '%s' roteada com similaridade %.4f", candidate.base.Name, candidate.score)
+		selected = append(selected, candidate.base)
+	}
+	return selected, nil
+}
+
+// descriptionEmbeddings retorna o embedding da descrição de cada base, gerando os que faltam. A chamada à
+// OpenAI é feita fora do lock para não bloquear o roteamento e a administração das bases.
+func (s *Service) descriptionEmbeddings(ctx context.Context, bases []*KnowledgeBase) ([][]float32, error) {
+	kb := s.bases
+	embeddings := make([][]float32, len(bases))
+	var missing []int
+	var descriptions []string
+
+	kb.mu.RLock()
+	for i, base := range bases {
+		if embedding, ok := kb.embeddings[base.Name]; ok {
+			embeddings[i] = embedding
+		} else {
+			missing = append(missing, i)
+			descriptions = append(descriptions, base.Description)
+		}
+	}
+	kb.mu.RUnlock()
+
+	if len(missing) == 0 {
+		return embeddings, nil
+	}
+
+	generated, err := s.openaiClient.GenerateEmbeddings(ctx, descriptions)
+	if err != nil {
+		s.logger.WithError(err).Error("Erro ao gerar embeddings das descrições das bases")
+		return nil, fmt.Errorf("erro ao gerar embeddings das descrições das bases: %w", err)
+	}
+
+	kb.mu.Lock()
+	defer kb.mu.Unlock()
+	for n, i := range missing {
+		embeddings[i] = generated[n]
+		// A descrição pode ter mudado durante a chamada; nesse caso o embedding não é guardado
+		if current, ok := kb.byName[bases[i].Name]; ok && current.Description == bases[i].Description {
+			kb.embeddings[bases[i].Name] = generated[n]
+		}
+	}
+	return embeddings, nil
+}
+
+// baseNames retorna os nomes das bases
+func baseNames(bases []*KnowledgeBase) []string {
+	names := make([]string, 0, len(bases))
+	for _, base := range bases {
+		names = append(names, base.Name)
+	}
+	return names
+}
+
+// searchBases busca o embedding em cada base e combina os resultados por score, que são
+// comparáveis porque todas as bases usam o mesmo modelo de embedding
+func (s *Service) searchBases(ctx context.Context, bases []*KnowledgeBase, queryEmbedding []float32, params qdrant.SearchParams) ([]models.RelevantDocument, error) {
+	var results []models.RelevantDocument
+	for _, base := range bases {
+		baseResults, err := base.Client.Search(ctx, queryEmbedding, params)
+		if err != nil {
+			return nil, err
+		}
+		for i := range baseResults {
+			baseResults[i].Collection = base.Name
+		}
+		results = append(results, baseResults...)
+	}
+
+	if len(bases) > 1 {
+		sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+		if len(results) > params.Limit {
+			results = results[:params.Limit]
+		}
+	}
+	return results, nil
+}
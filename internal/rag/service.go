@@ -2,7 +2,9 @@ package rag
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -10,31 +12,153 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/marcopollivier/rag-go-ex01/internal/chat"
+	"github.com/marcopollivier/rag-go-ex01/internal/experiment"
+	"github.com/marcopollivier/rag-go-ex01/internal/language"
 	"github.com/marcopollivier/rag-go-ex01/internal/models"
 	"github.com/marcopollivier/rag-go-ex01/internal/openai"
+	"github.com/marcopollivier/rag-go-ex01/internal/prompts"
 	"github.com/marcopollivier/rag-go-ex01/internal/qdrant"
+	"github.com/marcopollivier/rag-go-ex01/internal/rerank"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// defaultRerankCandidates é o número de candidatos buscados antes do reranking
+	defaultRerankCandidates = 20
+	// maxLLMRerankCandidates limita os candidatos enviados ao reranker llm, que faz uma chamada ao
+	// modelo a cada lote de trechos
+	maxLLMRerankCandidates = 50
+	// defaultMultiQueryCount é o número de reescritas geradas na expansão da pergunta
+	defaultMultiQueryCount = 3
+)
+
+// ErrInvalidRequest indica parâmetros de consulta que só podem ser validados pelo serviço
+var ErrInvalidRequest = errors.New("requisição inválida")
+
+// notFoundAnswers são as respostas devolvidas, por idioma, quando a política exige fundamentação
+// e a base não tem a informação
+var notFoundAnswers = map[string]string{
+	language.PortugueseBR: "Não encontrei informações sobre isso na base de conhecimento.",
+	language.English:      "I couldn't find information about this in the knowledge base.",
+	language.Spanish:      "No encontré información sobre esto en la base de conocimiento.",
+}
+
+// Config reúne as configurações do serviço RAG
+type Config struct {
+	// AnswerPolicy é a política de resposta padrão (models.AnswerPolicy*), usada pelas bases sem política própria
+	AnswerPolicy string
+	// ChatSessionTTL é a expiração padrão, por inatividade, das sessões de conversa
+	ChatSessionTTL time.Duration
+	// DefaultLanguage é o idioma de resposta quando não é possível detectar o idioma da pergunta
+	DefaultLanguage string
+	// DocumentsLanguage é o idioma dos documentos indexados
+	DocumentsLanguage string
+	// TranslateQueries traduz para DocumentsLanguage, em consultas e chat, as perguntas em outro idioma e
+	// funde as duas buscas; a requisição pode mudar isso em translate_query
+	TranslateQueries bool
+	// Experiments atribui variantes de experimento às consultas; nil desativa os experimentos
+	Experiments *experiment.Manager
+	// KnowledgeBasesFile é o arquivo onde as bases criadas, alteradas e removidas pela API são
+	// gravadas; vazio mantém as mudanças apenas em memória
+	KnowledgeBasesFile string
+	// Routing é o roteamento padrão entre bases de conhecimento (models.Routing*)
+	Routing string
+	// Tenants resolve os tenants e controla suas cotas diárias; nil desativa o isolamento por tenant
+	Tenants *tenant.Registry
+}
+
 type Service struct {
-	openaiClient *openai.Client
-	qdrantClient *qdrant.Client
-	logger       *logrus.Logger
+	config         Config
+	openaiClient   *openai.Client
+	bases          *knowledgeBases
+	promptStore    *prompts.Store
+	rerankers      map[string]rerank.Reranker
+	contextBuilder *contextBuilder
+	chatStore      *chat.Store
+	logger         *logrus.Logger
 }
 
 // NewService cria um novo serviço RAG
-func NewService(openaiClient *openai.Client, qdrantClient *qdrant.Client, logger *logrus.Logger) *Service {
+func NewService(openaiClient *openai.Client, bases []KnowledgeBase, promptStore *prompts.Store, config Config, logger *logrus.Logger) (*Service, error) {
+	builder, err := newContextBuilder(openaiClient.Model())
+	if err != nil {
+		return nil, err
+	}
+
+	knowledgeBases, err := newKnowledgeBases(bases)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.AnswerPolicy == "" {
+		config.AnswerPolicy = models.AnswerPolicyOpen
+	}
+	if config.ChatSessionTTL == 0 {
+		config.ChatSessionTTL = defaultChatSessionTTL
+	}
+	if config.DefaultLanguage == "" {
+		config.DefaultLanguage = language.PortugueseBR
+	}
+	if config.DocumentsLanguage == "" {
+		config.DocumentsLanguage = language.PortugueseBR
+	}
+	if config.Routing == "" {
+		config.Routing = models.RoutingEmbedding
+	}
+	err = config.Experiments.CheckPromptTemplates(func(name string) bool {
+		_, err := promptStore.Get(name)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return &Service{
+		config:       config,
 		openaiClient: openaiClient,
-		qdrantClient: qdrantClient,
-		logger:       logger,
-	}
+		bases:        knowledgeBases,
+		promptStore:  promptStore,
+		rerankers: map[string]rerank.Reranker{
+			rerank.Lexical: rerank.NewLexicalReranker(),
+			rerank.LLM:     rerank.NewLLMReranker(openaiClient),
+		},
+		contextBuilder: builder,
+		chatStore:      chat.NewStore(config.ChatSessionTTL),
+		logger:         logger,
+	}, nil
 }
 
-// IndexDocuments indexa uma lista de documentos
-func (s *Service) IndexDocuments(ctx context.Context, documents []models.Document) (*models.IndexResponse, error) {
+// IndexDocuments indexa uma lista de documentos na base de conhecimento informada (vazio para a padrão)
+func (s *Service) IndexDocuments(ctx context.Context, collection string, documents []models.Document) (*models.IndexResponse, error) {
 	startTime := time.Now()
-	s.logger.Infof("Iniciando indexação de %d documentos", len(documents))
+
+	base, err := s.base(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Infof("Iniciando indexação de %d documentos na base '%s'", len(documents), base.Name)
+
+	// IDs informados pelo cliente não podem sobrescrever documentos de outro tenant
+	var ids []string
+	for _, doc := range documents {
+		if doc.ID != "" {
+			ids = append(ids, doc.ID)
+		}
+	}
+	if err := base.Client.EnsureNotForeign(ctx, ids); err != nil {
+		s.logger.WithError(err).Warn("Indexação recusada: ID de documento de outro tenant")
+		return nil, err
+	}
+
+	// Documentos que falharem são devolvidos à cota ao final
+	t := tenant.FromContext(ctx)
+	if err := s.config.Tenants.ConsumeDocuments(t, len(documents)); err != nil {
+		s.logger.WithField("tenant", t.ID).Warn("Cota diária de documentos excedida")
+		return nil, err
+	}
 
 	var failedDocs []string
 	indexedCount := 0
@@ -54,7 +178,7 @@ func (s *Service) IndexDocuments(ctx context.Context, documents []models.Documen
 		}
 
 		// Indexar no Qdrant
-		if err := s.qdrantClient.IndexDocument(ctx, doc, embedding); err != nil {
+		if err := base.Client.IndexDocument(ctx, doc, embedding); err != nil {
 			s.logger.WithError(err).Errorf("Erro ao indexar documento %s", doc.ID)
 			failedDocs = append(failedDocs, doc.ID)
 			continue
@@ -63,6 +187,8 @@ func (s *Service) IndexDocuments(ctx context.Context, documents []models.Documen
 		indexedCount++
 	}
 
+	s.config.Tenants.ReleaseDocuments(t, len(failedDocs))
+
 	processingTime := time.Since(startTime)
 	s.logger.Infof("Indexação concluída: %d sucessos, %d falhas em %v",
 		indexedCount, len(failedDocs), processingTime)
@@ -77,48 +203,574 @@ func (s *Service) IndexDocuments(ctx context.Context, documents []models.Documen
 
 // Query executa uma consulta RAG
 func (s *Service) Query(ctx context.Context, req models.QueryRequest) (*models.QueryResponse, error) {
+	return s.query(ctx, req, nil)
+}
+
+// query executa uma consulta RAG, enviando ao modelo o histórico de conversa informado
+func (s *Service) query(ctx context.Context, req models.QueryRequest, history []models.ChatMessage) (*models.QueryResponse, error) {
+	startTime := time.Now()
+	queryID := uuid.New().String()
+	s.logger.Infof("Executando query RAG %s: %s", queryID, req.Query)
+
+	assignment := s.assignExperiment(&req)
+	applyQueryDefaults(&req)
+	s.applyLanguageDefaults(&req)
+
+	spec, err := s.resolveAnswerSpec(req, assignment)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.acceptQuery(ctx, req.Collections); err != nil {
+		return nil, err
+	}
+
+	prepared, err := s.prepareContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.applyAnswerDefaults(&req, prepared.collections)
+
+	gen, err := s.generateAnswer(ctx, req, spec, prepared.contextDocs, history, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	processingTime := time.Since(startTime)
+	s.logger.Infof("Query processada em %v com %d documentos relevantes",
+		processingTime, len(prepared.docs))
+	s.recordExposure(ctx, queryID, assignment, processingTime, gen.usage)
+
+	return &models.QueryResponse{
+		QueryID:              queryID,
+		Answer:               gen.answer,
+		Language:             req.Language,
+		AnswerPolicy:         req.AnswerPolicy,
+		AnswerPath:           gen.path,
+		RelevantDocs:         prepared.docs,
+		Collections:          prepared.collections,
+		RetrievalMode:        req.RetrievalMode,
+		RewrittenQueries:     prepared.rewrittenQueries,
+		HypotheticalDocument: prepared.hypotheticalDocument,
+		TranslatedQuery:      prepared.translatedQuery,
+		FusedQueries:         prepared.fusedQueries,
+		Context:              prepared.contextReport,
+		Citations:            gen.citations,
+		InvalidCitations:     gen.invalidCitations,
+		Structured:           gen.structured,
+		StructuredErrors:     gen.structuredErrors,
+		Usage:                gen.usage,
+		PromptTemplate:       spec.templateRef(),
+		Experiment:           spec.experimentRef(),
+		ProcessingTimeMs:     processingTime.Milliseconds(),
+	}, nil
+}
+
+// answerSpec reúne o template, o schema e a variante de experimento da geração, resolvidos antes
+// da recuperação para que parâmetros inválidos falhem sem custo de busca
+type answerSpec struct {
+	template   *prompts.Template
+	schema     *jsonschema.Schema
+	assignment *experiment.Assignment
+}
+
+// resolveAnswerSpec busca o template de prompt da requisição e compila o schema da resposta
+func (s *Service) resolveAnswerSpec(req models.QueryRequest, assignment *experiment.Assignment) (*answerSpec, error) {
+	template, err := s.promptStore.Get(req.PromptTemplate)
+	if errors.Is(err, prompts.ErrTemplateNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := compileResponseSchema(req.ResponseSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infof("Usando template de prompt '%s' (versão %s)", template.Name, template.Version)
+	return &answerSpec{template: template, schema: schema, assignment: assignment}, nil
+}
+
+// templateRef identifica o template usado, para registro na resposta
+func (spec *answerSpec) templateRef() *models.PromptTemplateRef {
+	return &models.PromptTemplateRef{Name: spec.template.Name, Version: spec.template.Version}
+}
+
+// experimentRef identifica a variante de experimento usada, para registro na resposta
+func (spec *answerSpec) experimentRef() *models.ExperimentAssignment {
+	if spec.assignment == nil {
+		return nil
+	}
+	return &models.ExperimentAssignment{
+		ExperimentID: spec.assignment.ExperimentID,
+		VariantID:    spec.assignment.Variant.ID,
+	}
+}
+
+// preparedContext reúne o resultado da recuperação e o contexto empacotado para o modelo
+type preparedContext struct {
+	*retrievalResult
+	contextDocs   []models.RelevantDocument
+	contextReport *models.ContextReport
+}
+
+// prepareContext recupera os documentos e empacota o contexto dentro do orçamento de tokens
+func (s *Service) prepareContext(ctx context.Context, req models.QueryRequest) (*preparedContext, error) {
+	result, err := s.retrieveDocuments(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	contextDocs, contextReport := s.contextBuilder.build(result.docs, req.ContextTokenBudget)
+	s.logger.Infof("Contexto montado com %d documentos (%d/%d tokens), %d descartados",
+		len(contextReport.Included), contextReport.UsedTokens, contextReport.TokenBudget, len(contextReport.Dropped))
+
+	return &preparedContext{
+		retrievalResult: result,
+		contextDocs:     contextDocs,
+		contextReport:   contextReport,
+	}, nil
+}
+
+// generation reúne a resposta gerada e seus metadados
+type generation struct {
+	answer           string
+	path             string
+	citations        []models.Citation
+	invalidCitations []int
+	structured       interface{}
+	structuredErrors []string
+	usage            *models.Usage
+}
+
+// generateAnswer gera a resposta conforme a política da requisição e informa o caminho seguido.
+// Com schema, a resposta é estruturada e validada; quando onDelta é informado, a resposta é gerada
+// em streaming e entregue trecho a trecho.
+func (s *Service) generateAnswer(ctx context.Context, req models.QueryRequest, spec *answerSpec, contextDocs []models.RelevantDocument, history []models.ChatMessage, onDelta func(string) error) (*generation, error) {
+	schema := spec.schema
+	groundedOnly := req.AnswerPolicy == models.AnswerPolicyGroundedOnly
+
+	if len(contextDocs) == 0 && groundedOnly {
+		s.logger.Info("Nenhum documento no contexto; abstendo-se conforme política grounded_only")
+		return s.abstain(req.Language, onDelta)
+	}
+
+	citations := req.Citations && schema == nil
+	opts := openai.AnswerOptions{
+		Template:     spec.template,
+		Citations:    citations,
+		Policy:       req.AnswerPolicy,
+		History:      history,
+		Language:     req.Language,
+		CrossLingual: req.Language != s.config.DocumentsLanguage,
+	}
+	if spec.assignment != nil {
+		opts.Model = spec.assignment.Variant.Model
+		opts.Temperature = spec.assignment.Variant.Temperature
+	}
+
+	var answer string
+	var usage models.Usage
+	var structured *structuredAnswer
+	var err error
+	switch {
+	case schema != nil:
+		opts.ResponseSchema = string(req.ResponseSchema)
+		structured, err = s.generateStructured(ctx, req.Query, contextDocs, opts, schema)
+		if err == nil {
+			answer, usage = structured.answer, structured.usage
+		}
+	case onDelta == nil:
+		answer, usage, err = s.openaiClient.GenerateAnswer(ctx, req.Query, contextDocs, opts)
+	default:
+		deliver := onDelta
+		var guard *markerGuard
+		if groundedOnly {
+			// Segurar os trechos enquanto a resposta ainda puder ser o marcador de contexto insuficiente
+			guard = newMarkerGuard(openai.InsufficientContextAnswer, onDelta)
+			deliver = guard.write
+		}
+
+		answer, usage, err = s.openaiClient.GenerateAnswerStream(ctx, req.Query, contextDocs, opts, deliver)
+		if err == nil && guard != nil && strings.TrimSpace(answer) != openai.InsufficientContextAnswer {
+			err = guard.flush()
+		}
+	}
+	if err != nil {
+		s.logger.WithError(err).Error("Erro ao gerar resposta")
+		return nil, fmt.Errorf("erro ao gerar resposta: %w", err)
+	}
+
+	path := models.AnswerPathKnowledgeBase
+	if len(contextDocs) == 0 {
+		path = models.AnswerPathGeneralKnowledge
+	}
+
+	if groundedOnly && strings.TrimSpace(answer) == openai.InsufficientContextAnswer {
+		s.logger.Info("Contexto insuficiente segundo o modelo; abstendo-se conforme política grounded_only")
+		gen, err := s.abstain(req.Language, onDelta)
+		if err != nil {
+			return nil, err
+		}
+		gen.usage = &usage
+		return gen, nil
+	}
+
+	gen := &generation{answer: answer, path: path, usage: &usage}
+	if structured != nil {
+		gen.structured, gen.structuredErrors = structured.value, structured.violations
+	}
+	if citations && len(contextDocs) > 0 {
+		gen.citations, gen.invalidCitations = extractCitations(answer, contextDocs)
+		if len(gen.invalidCitations) > 0 {
+			s.logger.Warnf("Resposta cita passagens inexistentes: %v", gen.invalidCitations)
+		}
+	}
+	return gen, nil
+}
+
+// abstain devolve a resposta padrão de informação não encontrada, entregando-a também no streaming
+func (s *Service) abstain(lang string, onDelta func(string) error) (*generation, error) {
+	answer, ok := notFoundAnswers[lang]
+	if !ok {
+		answer = notFoundAnswers[language.PortugueseBR]
+	}
+
+	if onDelta != nil {
+		if err := onDelta(answer); err != nil {
+			return nil, err
+		}
+	}
+	return &generation{answer: answer, path: models.AnswerPathAbstained}, nil
+}
+
+// Search recupera e ranqueia documentos sem gerar resposta, paginando por offset
+func (s *Service) Search(ctx context.Context, req models.SearchRequest) (*models.SearchResponse, error) {
+	if err := s.acceptQuery(ctx, req.Collections); err != nil {
+		return nil, err
+	}
+	return s.search(ctx, req)
+}
+
+// search executa a busca sem descontar a cota, para uso interno (ferramentas do agente)
+func (s *Service) search(ctx context.Context, req models.SearchRequest) (*models.SearchResponse, error) {
 	startTime := time.Now()
-	s.logger.Infof("Executando query RAG: %s", req.Query)
+	s.logger.Infof("Executando busca: %s (offset %d)", req.Query, req.Offset)
+
+	applyQueryDefaults(&req.QueryRequest)
+	s.applyLanguageDefaults(&req.QueryRequest)
+	pageSize := req.TopK
+
+	// A busca não gera resposta e só paga por uma chamada ao modelo quando o cliente pede a tradução
+	if req.TranslateQuery == nil {
+		translate := false
+		req.TranslateQuery = &translate
+	}
+
+	// Buscar um documento a mais que a página para saber se existe próxima página
+	retrievalReq := req.QueryRequest
+	retrievalReq.TopK = req.Offset + pageSize + 1
+
+	result, err := s.retrieveDocuments(ctx, retrievalReq)
+	if err != nil {
+		return nil, err
+	}
+
+	var page []models.RelevantDocument
+	if req.Offset < len(result.docs) {
+		page = result.docs[req.Offset:]
+	}
+
+	var nextOffset *int
+	if len(page) > pageSize {
+		page = page[:pageSize]
+		next := req.Offset + pageSize
+		nextOffset = &next
+	}
+
+	for i := range page {
+		page[i].Highlights = highlight(req.Query, page[i].Document.Content)
+	}
+
+	processingTime := time.Since(startTime)
+	s.logger.Infof("Busca processada em %v com %d resultados", processingTime, len(page))
+
+	return &models.SearchResponse{
+		Results:              page,
+		Count:                len(page),
+		Offset:               req.Offset,
+		NextOffset:           nextOffset,
+		Collections:          result.collections,
+		RetrievalMode:        req.RetrievalMode,
+		RewrittenQueries:     result.rewrittenQueries,
+		HypotheticalDocument: result.hypotheticalDocument,
+		TranslatedQuery:      result.translatedQuery,
+		FusedQueries:         result.fusedQueries,
+		ProcessingTimeMs:     processingTime.Milliseconds(),
+	}, nil
+}
 
-	// Configurar valores padrão
+// applyQueryDefaults configura os valores padrão de uma requisição de busca
+func applyQueryDefaults(req *models.QueryRequest) {
 	if req.TopK == 0 {
 		req.TopK = 5
 	}
 	if req.Threshold == 0 {
 		req.Threshold = 0.7
 	}
+	if req.RetrievalMode == "" {
+		req.RetrievalMode = models.RetrievalModeEmbedding
+	}
+	if req.ContextTokenBudget == 0 {
+		req.ContextTokenBudget = defaultContextTokenBudget
+	}
+}
+
+// applyLanguageDefaults resolve o idioma da resposta, detectando-o na pergunta quando não é informado
+func (s *Service) applyLanguageDefaults(req *models.QueryRequest) {
+	if req.Language != "" && req.Language != "auto" {
+		return
+	}
+
+	if detected, ok := language.Detect(req.Query); ok {
+		req.Language = detected
+		return
+	}
+	req.Language = s.config.DefaultLanguage
+}
+
+// policyStrictness ordena as políticas de resposta da mais aberta para a mais restritiva
+var policyStrictness = map[string]int{
+	models.AnswerPolicyOpen:              0,
+	models.AnswerPolicyGroundedPreferred: 1,
+	models.AnswerPolicyGroundedOnly:      2,
+}
+
+// applyAnswerDefaults aplica, quando a requisição não define uma política de resposta, a das bases
+// consultadas; se elas diferirem, vale a mais restritiva
+func (s *Service) applyAnswerDefaults(req *models.QueryRequest, collections []string) {
+	if req.AnswerPolicy != "" {
+		return
+	}
+
+	policy := s.config.AnswerPolicy
+	for i, name := range collections {
+		base, ok := s.bases.lookup(name)
+		if !ok {
+			continue
+		}
+		if basePolicy := s.basePolicy(base); i == 0 || policyStrictness[basePolicy] > policyStrictness[policy] {
+			policy = basePolicy
+		}
+	}
+	req.AnswerPolicy = policy
+}
+
+// basePolicy retorna a política de resposta da base ou, sem política própria, a padrão do serviço
+func (s *Service) basePolicy(base *KnowledgeBase) string {
+	if base.AnswerPolicy != "" {
+		return base.AnswerPolicy
+	}
+	return s.config.AnswerPolicy
+}
+
+// retrievalResult reúne os documentos recuperados e os artefatos intermediários da recuperação
+type retrievalResult struct {
+	docs                 []models.RelevantDocument
+	collections          []string
+	rewrittenQueries     []string
+	hypotheticalDocument string
+	translatedQuery      string
+	// fusedQueries é o número de consultas fundidas por RRF (zero quando há uma só)
+	fusedQueries int
+}
+
+// retrieveDocuments transforma a pergunta conforme a requisição (multi-query, HyDE, tradução
+// para o idioma dos documentos), gera os embeddings, escolhe as bases de conhecimento e recupera
+// os documentos relevantes
+func (s *Service) retrieveDocuments(ctx context.Context, req models.QueryRequest) (*retrievalResult, error) {
+	result := &retrievalResult{}
+
+	bases, err := s.requestedBases(req.Collections)
+	if err != nil {
+		return nil, err
+	}
+	if req.Routing == "" {
+		req.Routing = s.config.Routing
+	}
+
+	var queries []string
+	if req.RetrievalMode != models.RetrievalModeHyDE {
+		queries = append(queries, req.Query)
+	}
+
+	if req.RetrievalMode == models.RetrievalModeHyDE || req.RetrievalMode == models.RetrievalModeHyDEHybrid {
+		draft, err := s.openaiClient.GenerateHypotheticalDocument(ctx, req.Query)
+		if err != nil {
+			s.logger.WithError(err).Error("Erro ao gerar documento hipotético")
+			return nil, fmt.Errorf("erro ao gerar documento hipotético: %w", err)
+		}
+		s.logger.Infof("Documento hipotético gerado com %d caracteres", len(draft))
+		result.hypotheticalDocument = draft
+		queries = append(queries, draft)
+	}
 
-	queryEmbedding, err := s.openaiClient.GenerateEmbedding(ctx, req.Query)
+	translate := s.config.TranslateQueries
+	if req.TranslateQuery != nil {
+		translate = *req.TranslateQuery
+	}
+	if translate && req.Language != s.config.DocumentsLanguage {
+		translated, err := s.openaiClient.TranslateQuery(ctx, req.Query, s.config.DocumentsLanguage)
+		if err != nil {
+			s.logger.WithError(err).Error("Erro ao traduzir query")
+			return nil, fmt.Errorf("erro ao traduzir query: %w", err)
+		}
+		s.logger.Infof("Query traduzida de %s para %s: %s", req.Language, s.config.DocumentsLanguage, translated)
+		result.translatedQuery = translated
+		queries = append(queries, translated)
+	}
+
+	if req.MultiQuery {
+		if req.MultiQueryCount == 0 {
+			req.MultiQueryCount = defaultMultiQueryCount
+		}
+
+		rewrittenQueries, err := s.openaiClient.RewriteQuery(ctx, req.Query, req.MultiQueryCount)
+		if err != nil {
+			s.logger.WithError(err).Error("Erro ao reescrever query")
+			return nil, fmt.Errorf("erro ao reescrever query: %w", err)
+		}
+		s.logger.Infof("Query expandida em %d variações", len(rewrittenQueries))
+		result.rewrittenQueries = rewrittenQueries
+		queries = append(queries, rewrittenQueries...)
+	}
+
+	if len(queries) > 1 {
+		result.fusedQueries = len(queries)
+	}
+
+	queryEmbeddings, err := s.openaiClient.GenerateEmbeddings(ctx, queries)
 	if err != nil {
 		s.logger.WithError(err).Error("Erro ao gerar embedding da query")
 		return nil, fmt.Errorf("erro ao gerar embedding da query: %w", err)
 	}
 
-	relevantDocs, err := s.qdrantClient.SearchSimilar(ctx, queryEmbedding, req.TopK, req.Threshold)
+	if bases == nil {
+		bases, err = s.routeQuery(ctx, req, queryEmbeddings[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+	bases, err = s.scopeBases(ctx, bases)
 	if err != nil {
-		s.logger.WithError(err).Error("Erro ao buscar documentos similares")
-		return nil, fmt.Errorf("erro ao buscar documentos similares: %w", err)
+		return nil, err
 	}
+	result.collections = baseNames(bases)
+	s.logger.Infof("Buscando nas bases de conhecimento: %s", strings.Join(result.collections, ", "))
 
-	answer, err := s.openaiClient.GenerateAnswer(ctx, req.Query, relevantDocs)
+	result.docs, err = s.retrieve(ctx, req, bases, queryEmbeddings)
 	if err != nil {
-		s.logger.WithError(err).Error("Erro ao gerar resposta")
-		return nil, fmt.Errorf("erro ao gerar resposta: %w", err)
+		return nil, err
 	}
 
-	processingTime := time.Since(startTime)
-	s.logger.Infof("Query processada em %v com %d documentos relevantes",
-		processingTime, len(relevantDocs))
+	return result, nil
+}
 
-	return &models.QueryResponse{
-		Answer:           answer,
-		RelevantDocs:     relevantDocs,
-		ProcessingTimeMs: processingTime.Milliseconds(),
-	}, nil
+// retrieve busca os documentos relevantes para cada embedding, funde os resultados
+// e aplica reranking e diversificação quando solicitados
+func (s *Service) retrieve(ctx context.Context, req models.QueryRequest, bases []*KnowledgeBase, queryEmbeddings [][]float32) ([]models.RelevantDocument, error) {
+	limit := req.TopK
+	if req.Reranker != "" {
+		if req.RerankCandidates == 0 {
+			req.RerankCandidates = defaultRerankCandidates
+		}
+		if req.Reranker == rerank.LLM && req.RerankCandidates > maxLLMRerankCandidates {
+			req.RerankCandidates = maxLLMRerankCandidates
+		}
+		if req.RerankCandidates > limit {
+			limit = req.RerankCandidates
+		}
+	}
+	if req.MMR {
+		if req.MMRCandidates == 0 {
+			req.MMRCandidates = defaultMMRCandidates
+		}
+		if req.MMRCandidates > limit {
+			limit = req.MMRCandidates
+		}
+	}
+	if req.RecencyWeight > 0 && defaultRecencyCandidates > limit {
+		limit = defaultRecencyCandidates
+	}
+
+	var resultSets [][]models.RelevantDocument
+	for _, queryEmbedding := range queryEmbeddings {
+		results, err := s.searchBases(ctx, bases, queryEmbedding, qdrant.SearchParams{
+			Limit:      limit,
+			Threshold:  req.Threshold,
+			WithVector: req.MMR,
+			Filter:     req.SearchFilter,
+		})
+		if err != nil {
+			s.logger.WithError(err).Error("Erro ao buscar documentos similares")
+			return nil, fmt.Errorf("erro ao buscar documentos similares: %w", err)
+		}
+		resultSets = append(resultSets, results)
+	}
+
+	relevantDocs := resultSets[0]
+	if len(resultSets) > 1 {
+		relevantDocs = fuseResults(resultSets)
+	}
+
+	if req.RecencyWeight > 0 {
+		halfLife := req.RecencyHalfLifeDays
+		if halfLife == 0 {
+			halfLife = defaultRecencyHalfLifeDays
+		}
+		s.logger.Infof("Aplicando reforço por recência (peso %.2f, meia-vida %.0f dias)", req.RecencyWeight, halfLife)
+		relevantDocs = applyRecencyBoost(relevantDocs, req.RecencyWeight, halfLife, time.Now())
+	}
+
+	if req.Reranker != "" {
+		reranker, ok := s.rerankers[req.Reranker]
+		if !ok {
+			return nil, fmt.Errorf("reranker desconhecido: %s", req.Reranker)
+		}
+
+		// A fusão e o MMR podem trazer mais candidatos que o reranker llm aceita pontuar
+		if req.Reranker == rerank.LLM && len(relevantDocs) > maxLLMRerankCandidates {
+			relevantDocs = relevantDocs[:maxLLMRerankCandidates]
+		}
+
+		s.logger.Infof("Reordenando %d candidatos com reranker '%s'", len(relevantDocs), req.Reranker)
+		reranked, err := reranker.Rerank(ctx, req.Query, relevantDocs)
+		if err != nil {
+			s.logger.WithError(err).Error("Erro ao reordenar documentos")
+			return nil, fmt.Errorf("erro ao reordenar documentos: %w", err)
+		}
+		relevantDocs = reranked
+	}
+
+	if req.MMR {
+		lambda := float32(defaultMMRLambda)
+		if req.MMRLambda != nil {
+			lambda = *req.MMRLambda
+		}
+
+		s.logger.Infof("Diversificando %d candidatos com MMR (lambda %.2f)", len(relevantDocs), lambda)
+		return selectMMR(relevantDocs, req.TopK, lambda), nil
+	}
+
+	if len(relevantDocs) > req.TopK {
+		relevantDocs = relevantDocs[:req.TopK]
+	}
+	return relevantDocs, nil
 }
 
-// IndexTextFiles indexa arquivos de texto de uma pasta
-func (s *Service) IndexTextFiles(ctx context.Context, folderPath string) (*models.IndexResponse, error) {
+// IndexTextFiles indexa os arquivos de texto de uma pasta (incluindo subpastas) na base de conhecimento informada
+func (s *Service) IndexTextFiles(ctx context.Context, collection string, folderPath string) (*models.IndexResponse, error) {
 	s.logger.Infof("Indexando arquivos de texto da pasta: %s", folderPath)
 
 	var documents []models.Document
@@ -126,65 +778,156 @@ func (s *Service) IndexTextFiles(ctx context.Context, folderPath string) (*model
 	if _, err := os.Stat(folderPath); os.IsNotExist(err) {
 		s.logger.Warnf("Pasta %s não existe, criando documentos de exemplo", folderPath)
 	} else {
-		// Ler arquivos da pasta
-		files, err := ioutil.ReadDir(folderPath)
-		if err != nil {
-			return nil, fmt.Errorf("erro ao ler pasta %s: %w", folderPath, err)
-		}
-
-		for _, file := range files {
-			if file.IsDir() {
-				continue
+		// Ler arquivos da pasta e das subpastas
+		err := filepath.WalkDir(folderPath, func(filePath string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				return nil
 			}
 
 			// Processar apenas arquivos .txt
-			if !strings.HasSuffix(strings.ToLower(file.Name()), ".txt") {
-				continue
+			if !strings.HasSuffix(strings.ToLower(entry.Name()), ".txt") {
+				return nil
 			}
 
-			filePath := filepath.Join(folderPath, file.Name())
 			content, err := ioutil.ReadFile(filePath)
 			if err != nil {
 				s.logger.WithError(err).Warnf("Erro ao ler arquivo %s", filePath)
-				continue
+				return nil
 			}
 
 			// Criar documento
 			doc := models.Document{
 				ID:      uuid.New().String(),
 				Content: string(content),
-				Source:  file.Name(),
+				Source:  entry.Name(),
 				Metadata: map[string]string{
 					"file_path": filePath,
 					"file_size": fmt.Sprintf("%d", len(content)),
-					"language":  "portuguese",
+					"language":  s.config.DocumentsLanguage,
 				},
 				Created: time.Now(),
 			}
 
 			documents = append(documents, doc)
-			s.logger.Infof("Arquivo %s lido com %d caracteres", file.Name(), len(content))
+			s.logger.Infof("Arquivo %s lido com %d caracteres", entry.Name(), len(content))
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler pasta %s: %w", folderPath, err)
+		}
+	}
+
+	return s.IndexDocuments(ctx, collection, documents)
+}
+
+// IndexSampleData indexa a pasta de documentos de cada base de conhecimento que define uma
+func (s *Service) IndexSampleData(ctx context.Context) (*models.IndexResponse, error) {
+	startTime := time.Now()
+	response := &models.IndexResponse{Success: true}
+
+	for _, base := range s.bases.all() {
+		if base.DocumentsDir == "" {
+			continue
 		}
 
+		baseResponse, err := s.IndexTextFiles(ctx, base.Name, base.DocumentsDir)
+		if err != nil {
+			return nil, err
+		}
+		response.Success = response.Success && baseResponse.Success
+		response.IndexedCount += baseResponse.IndexedCount
+		response.FailedDocs = append(response.FailedDocs, baseResponse.FailedDocs...)
+	}
+
+	response.ProcessingTime = time.Since(startTime).String()
+	return response, nil
+}
+
+// FindSimilar recomenda documentos relacionados a um documento existente, usando seu vetor armazenado.
+// IDs positivos e negativos adicionais refinam a recomendação.
+func (s *Service) FindSimilar(ctx context.Context, collection string, docID string, positive, negative []string, limit int, threshold float32) ([]models.RelevantDocument, error) {
+	for _, id := range append(append([]string{docID}, positive...), negative...) {
+		if err := validateDocumentID(id); err != nil {
+			return nil, err
+		}
+	}
+
+	base, err := s.base(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Infof("Buscando documentos semelhantes a '%s' na base '%s' (limite: %d)", docID, base.Name, limit)
+
+	docs, err := base.Client.Recommend(ctx, append([]string{docID}, positive...), negative, limit, threshold)
+	if err != nil {
+		return nil, err
+	}
+	for i := range docs {
+		docs[i].Collection = base.Name
+	}
+	return docs, nil
+}
+
+// exportPageSize é o tamanho de página usado ao percorrer a coleção inteira
+const exportPageSize = 256
+
+// GetDocument retorna um documento pelo ID
+func (s *Service) GetDocument(ctx context.Context, collection string, docID string) (models.Document, error) {
+	if err := validateDocumentID(docID); err != nil {
+		return models.Document{}, err
 	}
+	base, err := s.base(ctx, collection)
+	if err != nil {
+		return models.Document{}, err
+	}
+	s.logger.Infof("Buscando documento '%s' na base '%s'", docID, base.Name)
+	return base.Client.GetDocument(ctx, docID)
+}
 
-	return s.IndexDocuments(ctx, documents)
+// GetAllDocuments retorna uma página de documentos indexados e o cursor da próxima página
+func (s *Service) GetAllDocuments(ctx context.Context, collection string, limit int, cursor string) ([]models.Document, string, error) {
+	base, err := s.base(ctx, collection)
+	if err != nil {
+		return nil, "", err
+	}
+	s.logger.Infof("Buscando todos os documentos da base '%s' (limite: %d)", base.Name, limit)
+	return base.Client.GetAllDocuments(ctx, limit, cursor)
 }
 
-// GetAllDocuments retorna todos os documentos indexados
-func (s *Service) GetAllDocuments(ctx context.Context, limit int) ([]models.Document, error) {
-	s.logger.Infof("Buscando todos os documentos (limite: %d)", limit)
-	return s.qdrantClient.GetAllDocuments(ctx, limit)
+// GetDocumentsBySource retorna uma página de documentos filtrados por fonte e o cursor da próxima página
+func (s *Service) GetDocumentsBySource(ctx context.Context, collection string, source string, limit int, cursor string) ([]models.Document, string, error) {
+	base, err := s.base(ctx, collection)
+	if err != nil {
+		return nil, "", err
+	}
+	s.logger.Infof("Buscando documentos da fonte '%s' na base '%s' (limite: %d)", source, base.Name, limit)
+	return base.Client.GetDocumentsBySource(ctx, source, limit, cursor)
+}
+
+// ExportDocuments percorre todos os documentos (opcionalmente de uma fonte), chamando fn para cada um
+func (s *Service) ExportDocuments(ctx context.Context, collection string, source string, fn func(models.Document) error) error {
+	base, err := s.base(ctx, collection)
+	if err != nil {
+		return err
+	}
+	s.logger.Infof("Exportando documentos da base '%s' (fonte: '%s')", base.Name, source)
+	return base.Client.WalkDocuments(ctx, source, exportPageSize, fn)
 }
 
-// GetDocumentsBySource retorna documentos filtrados por fonte
-func (s *Service) GetDocumentsBySource(ctx context.Context, source string, limit int) ([]models.Document, error) {
-	s.logger.Infof("Buscando documentos da fonte '%s' (limite: %d)", source, limit)
-	return s.qdrantClient.GetDocumentsBySource(ctx, source, limit)
+// ListPromptTemplates retorna os templates de prompt carregados
+func (s *Service) ListPromptTemplates() []prompts.Info {
+	return s.promptStore.List()
 }
 
-// GetCollectionInfo retorna informações sobre a coleção
-func (s *Service) GetCollectionInfo(ctx context.Context) (*qdrant.CollectionInfoResponse, error) {
-	s.logger.Info("Obtendo informações da coleção")
-	return s.qdrantClient.GetCollectionInfo(ctx)
+// GetCollectionInfo retorna informações sobre a coleção da base de conhecimento
+func (s *Service) GetCollectionInfo(ctx context.Context, collection string) (*qdrant.CollectionInfoResponse, error) {
+	base, err := s.base(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Infof("Obtendo informações da coleção da base '%s'", base.Name)
+	return base.Client.GetCollectionInfo(ctx)
 }
@@ -0,0 +1,147 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+// Eventos emitidos por QueryStream
+const (
+	// StreamEventSession identifica a sessão e a pergunta condensada (apenas em ChatStream)
+	StreamEventSession = "session"
+	// StreamEventDocuments traz os documentos recuperados, antes da geração
+	StreamEventDocuments = "documents"
+	// StreamEventToken traz um trecho da resposta
+	StreamEventToken = "token"
+	// StreamEventDone encerra o stream com tempos, consumo e citações
+	StreamEventDone = "done"
+)
+
+// QueryStream executa uma consulta RAG emitindo eventos à medida que o processamento avança:
+// primeiro os documentos recuperados, depois os trechos da resposta e, por fim, o resumo.
+func (s *Service) QueryStream(ctx context.Context, req models.QueryRequest, emit func(event string, data interface{}) error) error {
+	_, err := s.queryStream(ctx, req, nil, emit)
+	return err
+}
+
+// queryStream executa a consulta em streaming com o histórico de conversa informado e retorna a geração
+func (s *Service) queryStream(ctx context.Context, req models.QueryRequest, history []models.ChatMessage, emit func(event string, data interface{}) error) (*generation, error) {
+	startTime := time.Now()
+	queryID := uuid.New().String()
+	s.logger.Infof("Executando query RAG %s em streaming: %s", queryID, req.Query)
+
+	if err := checkStreamable(req); err != nil {
+		return nil, err
+	}
+	assignment := s.assignExperiment(&req)
+	applyQueryDefaults(&req)
+	s.applyLanguageDefaults(&req)
+
+	spec, err := s.resolveAnswerSpec(req, assignment)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.acceptQuery(ctx, req.Collections); err != nil {
+		return nil, err
+	}
+
+	prepared, err := s.prepareContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.applyAnswerDefaults(&req, prepared.collections)
+	retrievalTime := time.Since(startTime)
+
+	if err := emit(StreamEventDocuments, models.StreamDocumentsEvent{
+		RelevantDocs:         prepared.docs,
+		Collections:          prepared.collections,
+		Language:             req.Language,
+		RetrievalMode:        req.RetrievalMode,
+		RewrittenQueries:     prepared.rewrittenQueries,
+		HypotheticalDocument: prepared.hypotheticalDocument,
+		TranslatedQuery:      prepared.translatedQuery,
+		FusedQueries:         prepared.fusedQueries,
+		Context:              prepared.contextReport,
+	}); err != nil {
+		return nil, err
+	}
+
+	generationStart := time.Now()
+	gen, err := s.generateAnswer(ctx, req, spec, prepared.contextDocs, history, func(delta string) error {
+		return emit(StreamEventToken, models.StreamTokenEvent{Content: delta})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	processingTime := time.Since(startTime)
+	s.logger.Infof("Query em streaming processada em %v com %d documentos relevantes",
+		processingTime, len(prepared.docs))
+	s.recordExposure(ctx, queryID, assignment, processingTime, gen.usage)
+
+	return gen, emit(StreamEventDone, models.StreamDoneEvent{
+		AnswerPolicy:     req.AnswerPolicy,
+		AnswerPath:       gen.path,
+		Citations:        gen.citations,
+		InvalidCitations: gen.invalidCitations,
+		Usage:            gen.usage,
+		PromptTemplate:   spec.templateRef(),
+		QueryID:          queryID,
+		Experiment:       spec.experimentRef(),
+		RetrievalTimeMs:  retrievalTime.Milliseconds(),
+		GenerationTimeMs: time.Since(generationStart).Milliseconds(),
+		ProcessingTimeMs: processingTime.Milliseconds(),
+	})
+}
+
+// checkStreamable rejeita opções que dependem da resposta completa antes de entregá-la
+func checkStreamable(req models.QueryRequest) error {
+	if len(req.ResponseSchema) > 0 {
+		return fmt.Errorf("%w: response_schema não é suportado em streaming", ErrInvalidRequest)
+	}
+	return nil
+}
+
+// markerGuard retém os trechos do streaming enquanto o texto acumulado ainda puder ser o marcador
+// informado, para que o marcador nunca chegue ao cliente
+type markerGuard struct {
+	marker   string
+	deliver  func(string) error
+	buffered strings.Builder
+	released bool
+}
+
+func newMarkerGuard(marker string, deliver func(string) error) *markerGuard {
+	return &markerGuard{marker: marker, deliver: deliver}
+}
+
+// write recebe um trecho, entregando-o assim que o texto deixa de ser um prefixo do marcador
+func (g *markerGuard) write(delta string) error {
+	if g.released {
+		return g.deliver(delta)
+	}
+
+	g.buffered.WriteString(delta)
+	if strings.HasPrefix(g.marker, strings.TrimSpace(g.buffered.String())) {
+		return nil
+	}
+	return g.flush()
+}
+
+// flush entrega o texto retido
+func (g *markerGuard) flush() error {
+	if g.released {
+		return nil
+	}
+	g.released = true
+
+	if g.buffered.Len() == 0 {
+		return nil
+	}
+	return g.deliver(g.buffered.String())
+}
@@ -0,0 +1,156 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/openai"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const (
+	// maxStructuredAttempts é o número de gerações (a primeira mais as correções) de uma resposta estruturada
+	maxStructuredAttempts = 3
+	// responseSchemaURL identifica o schema da requisição no compilador
+	responseSchemaURL = "mem://response_schema.json"
+)
+
+// compileResponseSchema compila o JSON Schema da requisição. Referências externas ($ref para
+// arquivos ou URLs) não são resolvidas, para que o schema do chamador não leia recursos do servidor.
+// A raiz deve ser do tipo "object", pois o modelo é instruído a responder com um objeto JSON.
+func compileResponseSchema(raw json.RawMessage) (*jsonschema.Schema, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var root struct {
+		Type interface{} `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return nil, fmt.Errorf("%w: response_schema inválido: %v", ErrInvalidRequest, err)
+	}
+	if root.Type != "object" {
+		return nil, fmt.Errorf("%w: response_schema deve ter \"type\": \"object\" na raiz", ErrInvalidRequest)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("referência externa não permitida: %s", url)
+	}
+
+	if err := compiler.AddResource(responseSchemaURL, bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("%w: response_schema inválido: %v", ErrInvalidRequest, err)
+	}
+	schema, err := compiler.Compile(responseSchemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: response_schema inválido: %v", ErrInvalidRequest, err)
+	}
+	return schema, nil
+}
+
+// structuredAnswer é o resultado da geração de uma resposta estruturada
+type structuredAnswer struct {
+	answer     string
+	value      interface{}
+	violations []string
+	usage      models.Usage
+}
+
+// generateStructured gera uma resposta no formato do schema, pedindo ao modelo que corrija a saída
+// enquanto ela não for um JSON válido para o schema. Após a última tentativa, devolve o texto gerado
+// e as violações restantes.
+func (s *Service) generateStructured(ctx context.Context, query string, contextDocs []models.RelevantDocument, opts openai.AnswerOptions, schema *jsonschema.Schema) (*structuredAnswer, error) {
+	result := &structuredAnswer{}
+
+	for attempt := 1; attempt <= maxStructuredAttempts; attempt++ {
+		answer, usage, err := s.openaiClient.GenerateAnswer(ctx, query, contextDocs, opts)
+		if err != nil {
+			return nil, err
+		}
+		result.answer = answer
+		result.usage = addUsage(result.usage, usage)
+
+		// O marcador de contexto insuficiente é tratado pela política de resposta, não pelo schema
+		if strings.TrimSpace(answer) == openai.InsufficientContextAnswer {
+			result.value, result.violations = nil, nil
+			return result, nil
+		}
+
+		result.value, result.violations = parseStructuredAnswer(answer, schema)
+		if len(result.violations) == 0 {
+			s.logger.Infof("Resposta estruturada validada na tentativa %d", attempt)
+			return result, nil
+		}
+
+		s.logger.Warnf("Resposta estruturada inválida (tentativa %d/%d): %s",
+			attempt, maxStructuredAttempts, strings.Join(result.violations, "; "))
+		opts.Repair = &openai.SchemaRepair{Answer: answer, Violations: result.violations}
+	}
+
+	return result, nil
+}
+
+// parseStructuredAnswer extrai o objeto JSON da resposta e o valida contra o schema,
+// retornando o valor decodificado ou as violações encontradas
+func parseStructuredAnswer(answer string, schema *jsonschema.Schema) (interface{}, []string) {
+	start := strings.Index(answer, "{")
+	end := strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return nil, []string{"a resposta não contém um objeto JSON"}
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(answer[start : end+1]))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, []string{fmt.Sprintf("JSON inválido: %v", err)}
+	}
+
+	if err := schema.Validate(value); err != nil {
+		return nil, schemaViolations(err)
+	}
+	return value, nil
+}
+
+// schemaViolations lista as violações de validação como "local: mensagem", usando as causas mais específicas
+func schemaViolations(err error) []string {
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return []string{err.Error()}
+	}
+
+	var violations []string
+	var collect func(*jsonschema.ValidationError)
+	collect = func(ve *jsonschema.ValidationError) {
+		if len(ve.Causes) == 0 {
+			location := ve.InstanceLocation
+			if location == "" {
+				location = "/"
+			}
+			violations = append(violations, fmt.Sprintf("%s: %s", location, ve.Message))
+			return
+		}
+		for _, cause := range ve.Causes {
+			collect(cause)
+		}
+	}
+	collect(validationErr)
+
+	return violations
+}
+
+// addUsage soma o consumo de tokens de duas chamadas
+func addUsage(a, b models.Usage) models.Usage {
+	return models.Usage{
+		PromptTokens:     a.PromptTokens + b.PromptTokens,
+		CompletionTokens: a.CompletionTokens + b.CompletionTokens,
+		TotalTokens:      a.TotalTokens + b.TotalTokens,
+		Estimated:        a.Estimated || b.Estimated,
+	}
+}
@@ -0,0 +1,111 @@
+package rag
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+const recipeSchema = `{
+	"type": "object",
+	"properties": {
+		"nome": {"type": "string"},
+		"minutos": {"type": "integer", "minimum": 1}
+	},
+	"required": ["nome", "minutos"]
+}`
+
+func TestCompileResponseSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "sem schema", schema: "", wantNil: true},
+		{name: "objeto", schema: recipeSchema},
+		{name: "raiz array", schema: `{"type": "array", "items": {"type": "string"}}`, wantErr: true},
+		{name: "raiz sem tipo", schema: `{"properties": {"nome": {"type": "string"}}}`, wantErr: true},
+		{name: "vários tipos na raiz", schema: `{"type": ["object", "null"]}`, wantErr: true},
+		{name: "JSON inválido", schema: `{"type": `, wantErr: true},
+		{name: "referência externa", schema: `{"type": "object", "properties": {"a": {"$ref": "file:///etc/passwd"}}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := compileResponseSchema(json.RawMessage(tt.schema))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compileResponseSchema() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("compileResponseSchema() error = %v, want ErrInvalidRequest", err)
+			}
+			if !tt.wantErr && (schema == nil) != tt.wantNil {
+				t.Errorf("compileResponseSchema() = %v, want nil %v", schema, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestParseStructuredAnswer(t *testing.T) {
+	schema, err := compileResponseSchema(json.RawMessage(recipeSchema))
+	if err != nil {
+		t.Fatalf("compileResponseSchema() error = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		answer         string
+		want           interface{}
+		wantViolations []string
+	}{
+		{
+			name:   "objeto válido",
+			answer: `{"nome": "Brigadeiro", "minutos": 15}`,
+			want:   map[string]interface{}{"nome": "Brigadeiro", "minutos": json.Number("15")},
+		},
+		{
+			name:   "objeto em bloco de código",
+			answer: "```json\n{\"nome\": \"Brigadeiro\", \"minutos\": 15}\n```",
+			want:   map[string]interface{}{"nome": "Brigadeiro", "minutos": json.Number("15")},
+		},
+		{
+			name:           "sem objeto",
+			answer:         "Leva quinze minutos.",
+			wantViolations: []string{"a resposta não contém um objeto JSON"},
+		},
+		{
+			name:           "campo obrigatório ausente",
+			answer:         `{"nome": "Brigadeiro"}`,
+			wantViolations: []string{"/: missing properties: 'minutos'"},
+		},
+		{
+			name:           "violações em vários campos",
+			answer:         `{"nome": 1, "minutos": 0}`,
+			wantViolations: []string{"/minutos: must be >= 1 but found 0", "/nome: expected string, but got number"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, violations := parseStructuredAnswer(tt.answer, schema)
+			sort.Strings(violations)
+
+			if !reflect.DeepEqual(violations, tt.wantViolations) {
+				t.Errorf("parseStructuredAnswer() violations = %q, want %q", violations, tt.wantViolations)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStructuredAnswer() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaViolationsWithoutValidationError(t *testing.T) {
+	got := schemaViolations(errors.New("falha ao validar"))
+	if want := []string{"falha ao validar"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("schemaViolations() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/knowledge"
+	"github.com/marcopollivier/rag-go-ex01/internal/qdrant"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
+)
+
+// tenantCollection retorna o nome da coleção própria do tenant em uma base. O prefixo reservado e o
+// separador '.', que não aparece em IDs de tenant nem em coleções criadas pela API, tornam o nome
+// inequívoco: bases e tenants diferentes nunca compartilham uma coleção.
+func tenantCollection(base *KnowledgeBase, t *tenant.Tenant) string {
+	return fmt.Sprintf("%s%s.%s", knowledge.TenantCollectionPrefix, t.ID, base.Collection)
+}
+
+// scoped retorna a base com o cliente restrito ao tenant da requisição: a coleção própria do tenant
+// ou a coleção compartilhada com filtro obrigatório pelo tenant. Sem tenant, retorna a própria base.
+func (s *Service) scoped(ctx context.Context, base *KnowledgeBase) (*KnowledgeBase, error) {
+	t := tenant.FromContext(ctx)
+	if t == nil {
+		return base, nil
+	}
+
+	scopedBase := &KnowledgeBase{Base: base.Base}
+	if t.Isolation == tenant.IsolationFilter {
+		scopedBase.Client = base.Client.WithTenant(t.ID)
+		return scopedBase, nil
+	}
+
+	client, err := s.bases.tenantClient(ctx, base, t)
+	if err != nil {
+		return nil, err
+	}
+	scopedBase.Client = client
+	scopedBase.Collection = client.CollectionName()
+	return scopedBase, nil
+}
+
+// scopeBases aplica scoped a cada base
+func (s *Service) scopeBases(ctx context.Context, bases []*KnowledgeBase) ([]*KnowledgeBase, error) {
+	scopedBases := make([]*KnowledgeBase, 0, len(bases))
+	for _, base := range bases {
+		scopedBase, err := s.scoped(ctx, base)
+		if err != nil {
+			return nil, err
+		}
+		scopedBases = append(scopedBases, scopedBase)
+	}
+	return scopedBases, nil
+}
+
+// tenantClient retorna o cliente da coleção própria do tenant na base, criando a coleção no primeiro uso
+func (kb *knowledgeBases) tenantClient(ctx context.Context, base *KnowledgeBase, t *tenant.Tenant) (*qdrant.Client, error) {
+	kb.clientsMu.Lock()
+	defer kb.clientsMu.Unlock()
+
+	key := base.Name + "/" + t.ID
+	if client, ok := kb.tenantClients[key]; ok {
+		return client, nil
+	}
+
+	client, err := base.Client.WithCollection(ctx, tenantCollection(base, t))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao preparar coleção do tenant '%s': %w", t.ID, err)
+	}
+	kb.tenantClients[key] = client
+	return client, nil
+}
+
+// consumeQuery desconta uma consulta da cota diária do tenant da requisição
+func (s *Service) consumeQuery(ctx context.Context) error {
+	t := tenant.FromContext(ctx)
+	if err := s.config.Tenants.ConsumeQuery(t); err != nil {
+		s.logger.WithField("tenant", t.ID).Warn("Cota diária de consultas excedida")
+		return err
+	}
+	return nil
+}
+
+// acceptQuery confere os parâmetros da consulta que só o serviço valida e só então desconta a
+// consulta da cota, para que requisições recusadas não consumam a cota do tenant
+func (s *Service) acceptQuery(ctx context.Context, collections []string) error {
+	if _, err := s.requestedBases(collections); err != nil {
+		return err
+	}
+	return s.consumeQuery(ctx)
+}
+
+// TenantUsage retorna o tenant da requisição e seu consumo no dia (nil sem tenants)
+func (s *Service) TenantUsage(ctx context.Context) (*tenant.Tenant, tenant.Usage) {
+	t := tenant.FromContext(ctx)
+	return t, s.config.Tenants.Usage(t)
+}
@@ -0,0 +1,252 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/qdrant"
+	"github.com/marcopollivier/rag-go-ex01/internal/tenant"
+)
+
+const (
+	// importBatchSize é o número de registros gravados por requisição ao Qdrant na importação
+	importBatchSize = 100
+	// maxImportErrors limita as falhas descritas na resposta da importação
+	maxImportErrors = 50
+	// maxImportVectorSize é a maior dimensão aceita ao criar a coleção na importação
+	maxImportVectorSize = 65536
+)
+
+// importDistances são as métricas de distância aceitas ao criar a coleção na importação
+var importDistances = map[string]bool{"Cosine": true, "Dot": true, "Euclid": true, "Manhattan": true}
+
+// ExportCollection percorre todos os pontos da base, chamando fn com o ID, o payload e,
+// com withVectors, o vetor de cada um
+func (s *Service) ExportCollection(ctx context.Context, name string, withVectors bool, fn func(models.PointRecord) error) error {
+	base, err := s.namedBase(ctx, name)
+	if err != nil {
+		return err
+	}
+	s.logger.Infof("Exportando coleção da base '%s' (vetores: %t)", base.Name, withVectors)
+
+	return base.Client.WalkPoints(ctx, withVectors, exportPageSize, func(point qdrant.PointStruct) error {
+		return fn(models.PointRecord{ID: point.ID, Payload: point.Payload, Vector: point.Vector})
+	})
+}
+
+// importRecord é um registro lido do arquivo de importação, com a linha de origem
+type importRecord struct {
+	line   int
+	record models.PointRecord
+}
+
+// importLine é uma linha do arquivo de importação: um ponto ou, na primeira linha, o cabeçalho da coleção
+type importLine struct {
+	models.PointRecord
+	Collection *models.CollectionHeader `json:"collection,omitempty"`
+}
+
+// ImportCollection grava na base os pontos lidos em JSONL, preservando IDs e payloads. Registros com
+// vetor são gravados sem gerar embeddings; os sem vetor têm o embedding gerado a partir de
+// payload.content. A primeira linha pode ser o cabeçalho gerado pela exportação; com
+// opts.CreateIfMissing, uma base inexistente é criada com a dimensão e a distância do cabeçalho (ou
+// a dimensão do primeiro vetor). A importação conta na cota de documentos do tenant. Em caso de
+// erro, a resposta traz o que já foi importado.
+func (s *Service) ImportCollection(ctx context.Context, name string, r io.Reader, opts models.ImportOptions) (*models.ImportResponse, error) {
+	startTime := time.Now()
+
+	response := &models.ImportResponse{}
+	decoder := json.NewDecoder(r)
+
+	var base *KnowledgeBase
+	var vectorSize int
+	var batch []importRecord
+	for line := 1; ; line++ {
+		var entry importLine
+		err := decoder.Decode(&entry)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			response.ProcessingTime = time.Since(startTime).String()
+			return response, fmt.Errorf("%w: linha %d não é um JSON válido: %v", ErrInvalidRequest, line, err)
+		}
+		if entry.Collection != nil && line > 1 {
+			response.ProcessingTime = time.Since(startTime).String()
+			return response, fmt.Errorf("%w: linha %d: o cabeçalho da coleção só é aceito na primeira linha", ErrInvalidRequest, line)
+		}
+
+		if base == nil {
+			if base, err = s.importTarget(ctx, name, entry, opts, response); err != nil {
+				response.ProcessingTime = time.Since(startTime).String()
+				return response, err
+			}
+			vectorSize = base.Client.Params().VectorSize
+			s.logger.Infof("Importando coleção na base '%s'", base.Name)
+		}
+		if entry.Collection != nil {
+			continue
+		}
+
+		record := entry.PointRecord
+		if reason := validateImportRecord(&record, vectorSize); reason != "" {
+			s.recordImportFailure(response, line, reason)
+			continue
+		}
+
+		batch = append(batch, importRecord{line: line, record: record})
+		if len(batch) == importBatchSize {
+			if err := s.importBatch(ctx, base, batch, response); err != nil {
+				response.ProcessingTime = time.Since(startTime).String()
+				return response, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if base == nil {
+		// Arquivo vazio: nada a importar, mas a base ainda precisa existir
+		if _, err := s.namedBase(ctx, name); err != nil {
+			response.ProcessingTime = time.Since(startTime).String()
+			return response, err
+		}
+	} else if err := s.importBatch(ctx, base, batch, response); err != nil {
+		response.ProcessingTime = time.Since(startTime).String()
+		return response, err
+	}
+
+	response.ProcessingTime = time.Since(startTime).String()
+	s.logger.Infof("Importação concluída: %d importados (%d com embedding gerado), %d falhas em %s",
+		response.Imported, response.Embedded, response.Failed, response.ProcessingTime)
+	return response, nil
+}
+
+// importTarget retorna a base de destino da importação, criando-a a partir da primeira linha do
+// arquivo quando ela não existe e opts.CreateIfMissing está ativo
+func (s *Service) importTarget(ctx context.Context, name string, first importLine, opts models.ImportOptions, response *models.ImportResponse) (*KnowledgeBase, error) {
+	base, err := s.namedBase(ctx, name)
+	if !errors.Is(err, ErrKnowledgeBaseNotFound) || !opts.CreateIfMissing {
+		return base, err
+	}
+
+	req := models.CreateCollectionRequest{
+		Name:        name,
+		Description: "Base importada de arquivo",
+	}
+	if header := first.Collection; header != nil {
+		if header.Description != "" {
+			req.Description = header.Description
+		}
+		req.VectorSize = header.VectorSize
+		req.Distance = header.Distance
+	} else {
+		req.VectorSize = len(first.Vector)
+	}
+	if opts.Distance != "" {
+		req.Distance = opts.Distance
+	}
+	if req.VectorSize > maxImportVectorSize {
+		return nil, fmt.Errorf("%w: dimensão dos vetores %d acima do limite de %d", ErrInvalidRequest, req.VectorSize, maxImportVectorSize)
+	}
+	if req.Distance != "" && !importDistances[req.Distance] {
+		return nil, fmt.Errorf("%w: distância '%s' inválida (use Cosine, Dot, Euclid ou Manhattan)", ErrInvalidRequest, req.Distance)
+	}
+
+	s.logger.Infof("Base '%s' não existe; criando a partir do arquivo de importação", name)
+	if _, err := s.CreateKnowledgeBase(ctx, req); err != nil {
+		return nil, err
+	}
+	response.Created = true
+	return s.namedBase(ctx, name)
+}
+
+// validateImportRecord confere o registro, gerando um ID quando ausente, e retorna o motivo da recusa
+// (vazio quando válido)
+func validateImportRecord(record *models.PointRecord, vectorSize int) string {
+	if record.ID == "" {
+		record.ID = models.PointID(uuid.New().String())
+	} else if !isPointID(record.ID) {
+		return fmt.Sprintf("ID '%s' não é um UUID nem um inteiro sem sinal", record.ID)
+	}
+
+	if len(record.Vector) > 0 {
+		if len(record.Vector) != vectorSize {
+			return fmt.Sprintf("vetor com %d dimensões, a coleção usa %d", len(record.Vector), vectorSize)
+		}
+		return ""
+	}
+
+	if content, _ := record.Payload["content"].(string); content == "" {
+		return "registro sem vetor e sem payload.content para gerar o embedding"
+	}
+	return ""
+}
+
+// importBatch desconta o lote da cota do tenant, gera os embeddings que faltam e grava o lote
+func (s *Service) importBatch(ctx context.Context, base *KnowledgeBase, batch []importRecord, response *models.ImportResponse) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	t := tenant.FromContext(ctx)
+	if err := s.config.Tenants.ConsumeDocuments(t, len(batch)); err != nil {
+		s.logger.WithField("tenant", t.ID).Warn("Cota diária de documentos excedida na importação")
+		return err
+	}
+
+	var missing []int
+	var contents []string
+	for i, item := range batch {
+		if len(item.record.Vector) == 0 {
+			missing = append(missing, i)
+			contents = append(contents, item.record.Payload["content"].(string))
+		}
+	}
+
+	var embeddings [][]float32
+	if len(contents) > 0 {
+		var err error
+		embeddings, err = s.openaiClient.GenerateEmbeddings(ctx, contents)
+		if err != nil {
+			s.config.Tenants.ReleaseDocuments(t, len(batch))
+			s.logger.WithError(err).Error("Erro ao gerar embeddings na importação")
+			return fmt.Errorf("erro ao gerar embeddings: %w", err)
+		}
+	}
+
+	points := make([]qdrant.PointStruct, 0, len(batch))
+	for _, item := range batch {
+		points = append(points, qdrant.PointStruct{ID: item.record.ID, Vector: item.record.Vector, Payload: item.record.Payload})
+	}
+	for i, index := range missing {
+		points[index].Vector = embeddings[i]
+	}
+
+	if err := base.Client.UpsertPoints(ctx, points); err != nil {
+		s.config.Tenants.ReleaseDocuments(t, len(points))
+		if errors.Is(err, qdrant.ErrForeignPoint) {
+			return fmt.Errorf("lote da linha %d: %w", batch[0].line, err)
+		}
+		s.logger.WithError(err).Error("Erro ao gravar lote da importação")
+		return err
+	}
+
+	response.Imported += len(points)
+	response.Embedded += len(missing)
+	return nil
+}
+
+// recordImportFailure conta um registro recusado, descrevendo-o enquanto houver espaço na resposta
+func (s *Service) recordImportFailure(response *models.ImportResponse, line int, reason string) {
+	response.Failed++
+	if len(response.Errors) < maxImportErrors {
+		response.Errors = append(response.Errors, fmt.Sprintf("linha %d: %s", line, reason))
+	}
+	s.logger.Warnf("Linha %d recusada na importação: %s", line, reason)
+}
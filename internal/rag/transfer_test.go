@@ -0,0 +1,77 @@
+package rag
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+func TestValidateImportRecord(t *testing.T) {
+	const vectorSize = 3
+
+	tests := []struct {
+		name       string
+		record     models.PointRecord
+		wantReason string
+		wantNewID  bool
+	}{
+		{
+			name:   "UUID com vetor",
+			record: models.PointRecord{ID: "5c56c793-69f3-4fbf-87e6-c4bf54c28c26", Vector: []float32{1, 0, 0}},
+		},
+		{
+			name:   "ID numérico com conteúdo",
+			record: models.PointRecord{ID: "42", Payload: map[string]interface{}{"content": "Receita de brigadeiro."}},
+		},
+		{
+			name:      "sem ID recebe um UUID",
+			record:    models.PointRecord{Vector: []float32{1, 0, 0}},
+			wantNewID: true,
+		},
+		{
+			name:       "ID que não é UUID nem inteiro",
+			record:     models.PointRecord{ID: "receita-1", Vector: []float32{1, 0, 0}},
+			wantReason: "ID 'receita-1' não é um UUID nem um inteiro sem sinal",
+		},
+		{
+			name:       "ID negativo",
+			record:     models.PointRecord{ID: "-7", Vector: []float32{1, 0, 0}},
+			wantReason: "ID '-7' não é um UUID nem um inteiro sem sinal",
+		},
+		{
+			name:       "dimensão diferente da coleção",
+			record:     models.PointRecord{ID: "1", Vector: []float32{1, 0}},
+			wantReason: "vetor com 2 dimensões, a coleção usa 3",
+		},
+		{
+			name:       "sem vetor e sem conteúdo",
+			record:     models.PointRecord{ID: "1", Payload: map[string]interface{}{"source": "receitas.txt"}},
+			wantReason: "registro sem vetor e sem payload.content para gerar o embedding",
+		},
+		{
+			name:       "conteúdo que não é texto",
+			record:     models.PointRecord{ID: "1", Payload: map[string]interface{}{"content": 10}},
+			wantReason: "registro sem vetor e sem payload.content para gerar o embedding",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := tt.record
+			if got := validateImportRecord(&record, vectorSize); got != tt.wantReason {
+				t.Errorf("validateImportRecord() = %q, want %q", got, tt.wantReason)
+			}
+
+			if !tt.wantNewID {
+				if record.ID != tt.record.ID {
+					t.Errorf("validateImportRecord() alterou o ID para %q", record.ID)
+				}
+				return
+			}
+			if _, err := uuid.Parse(string(record.ID)); err != nil {
+				t.Errorf("ID gerado %q não é um UUID", record.ID)
+			}
+		})
+	}
+}
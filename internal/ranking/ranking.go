@@ -0,0 +1,82 @@
+// Package ranking define a ordem dos documentos em todos os estágios da recuperação: fusão RRF,
+// reforço por recência, reranking e MMR usam o mesmo score de ranking
+package ranking
+
+import (
+	"sort"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+// Relevance retorna a relevância do estágio mais recente pelo qual o documento passou: o score do
+// reranker, o score fundido (RRF) ou o score vetorial
+func Relevance(doc models.RelevantDocument) float32 {
+	switch {
+	case doc.RerankScore != nil:
+		return *doc.RerankScore
+	case doc.FusedScore != nil:
+		return *doc.FusedScore
+	default:
+		return doc.Score
+	}
+}
+
+// NormalizedRelevance retorna a relevância de cada documento normalizada para [0, 1] entre os
+// candidatos, para que estágios com escalas diferentes (cosseno, RRF, notas de 0 a 10 do LLM) sejam
+// comparáveis. Candidatos com a mesma relevância recebem 1.
+func NormalizedRelevance(docs []models.RelevantDocument) []float32 {
+	normalized := make([]float32, len(docs))
+	if len(docs) == 0 {
+		return normalized
+	}
+
+	min, max := Relevance(docs[0]), Relevance(docs[0])
+	for _, doc := range docs[1:] {
+		relevance := Relevance(doc)
+		if relevance < min {
+			min = relevance
+		}
+		if relevance > max {
+			max = relevance
+		}
+	}
+
+	for i, doc := range docs {
+		if max == min {
+			normalized[i] = 1
+			continue
+		}
+		normalized[i] = (Relevance(doc) - min) / (max - min)
+	}
+	return normalized
+}
+
+// Scores retorna o score de ranking de cada documento: a relevância normalizada somada ao reforço
+// por recência, que é pequeno o bastante para decidir apenas empates e quase empates
+func Scores(docs []models.RelevantDocument) []float32 {
+	scores := NormalizedRelevance(docs)
+	for i, doc := range docs {
+		if doc.RecencyBoost != nil {
+			scores[i] += *doc.RecencyBoost
+		}
+	}
+	return scores
+}
+
+// Sort ordena os documentos pelo score de ranking; empates mantêm a ordem do estágio anterior
+func Sort(docs []models.RelevantDocument) {
+	scores := Scores(docs)
+	order := make([]int, len(docs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+
+	sorted := make([]models.RelevantDocument, len(docs))
+	for i, index := range order {
+		sorted[i] = docs[index]
+	}
+	copy(docs, sorted)
+}
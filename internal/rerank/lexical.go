@@ -0,0 +1,58 @@
+package rerank
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+// LexicalReranker pontua os candidatos pela fração dos termos da query presentes no documento
+type LexicalReranker struct{}
+
+// NewLexicalReranker cria um reranker lexical
+func NewLexicalReranker() *LexicalReranker {
+	return &LexicalReranker{}
+}
+
+// Rerank reordena os documentos pela sobreposição de termos com a query
+func (r *LexicalReranker) Rerank(ctx context.Context, query string, docs []models.RelevantDocument) ([]models.RelevantDocument, error) {
+	queryTerms := Terms(query)
+
+	reranked := make([]models.RelevantDocument, len(docs))
+	copy(reranked, docs)
+
+	for i := range reranked {
+		score := float32(0)
+		if len(queryTerms) > 0 {
+			docTerms := Terms(reranked[i].Document.Content)
+			matches := 0
+			for term := range queryTerms {
+				if _, ok := docTerms[term]; ok {
+					matches++
+				}
+			}
+			score = float32(matches) / float32(len(queryTerms))
+		}
+		reranked[i].RerankScore = &score
+	}
+
+	sortByRerankScore(reranked)
+	return reranked, nil
+}
+
+// Terms extrai o conjunto de termos normalizados (minúsculos, com 3 ou mais caracteres) de um texto
+func Terms(text string) map[string]struct{} {
+	terms := make(map[string]struct{})
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if len([]rune(word)) < 3 {
+			continue
+		}
+		terms[word] = struct{}{}
+	}
+	return terms
+}
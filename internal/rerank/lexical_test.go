@@ -0,0 +1,111 @@
+package rerank
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+)
+
+func lexicalDoc(id string, score float32, content string) models.RelevantDocument {
+	return models.RelevantDocument{
+		Document: models.Document{ID: id, Content: content},
+		Score:    score,
+	}
+}
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "minúsculas e pontuação", text: "Brigadeiro, CHOCOLATE e manteiga!", want: []string{"brigadeiro", "chocolate", "manteiga"}},
+		{name: "palavras curtas são ignoradas", text: "um pé de moleque", want: []string{"moleque"}},
+		{name: "acentos contam como letras", text: "Ação já é útil", want: []string{"ação", "útil"}},
+		{name: "números são termos", text: "forno a 180 graus", want: []string{"180", "forno", "graus"}},
+		{name: "termos repetidos contam uma vez", text: "bolo bolo BOLO", want: []string{"bolo"}},
+		{name: "texto vazio", text: "", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for term := range Terms(tt.text) {
+				got = append(got, term)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Terms(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLexicalRerank(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		docs       []models.RelevantDocument
+		want       []string
+		wantScores []float32
+	}{
+		{
+			name:  "ordena pela fração de termos da query",
+			query: "brigadeiro de chocolate",
+			docs: []models.RelevantDocument{
+				lexicalDoc("bolo", 0.9, "Bolo de cenoura com cobertura."),
+				lexicalDoc("parcial", 0.8, "Calda de chocolate."),
+				lexicalDoc("completo", 0.7, "Brigadeiro de chocolate ao leite."),
+			},
+			want:       []string{"completo", "parcial", "bolo"},
+			wantScores: []float32{1, 0.5, 0},
+		},
+		{
+			name:  "empate mantém a ordem da busca vetorial",
+			query: "chocolate",
+			docs: []models.RelevantDocument{
+				lexicalDoc("a", 0.9, "Chocolate amargo."),
+				lexicalDoc("b", 0.8, "Chocolate branco."),
+			},
+			want:       []string{"a", "b"},
+			wantScores: []float32{1, 1},
+		},
+		{
+			name:  "query sem termos zera os scores",
+			query: "o e a",
+			docs: []models.RelevantDocument{
+				lexicalDoc("a", 0.9, "Chocolate amargo."),
+				lexicalDoc("b", 0.8, "Chocolate branco."),
+			},
+			want:       []string{"a", "b"},
+			wantScores: []float32{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewLexicalReranker().Rerank(context.Background(), tt.query, tt.docs)
+			if err != nil {
+				t.Fatalf("Rerank() error = %v", err)
+			}
+
+			ids := make([]string, 0, len(got))
+			for i, doc := range got {
+				ids = append(ids, doc.Document.ID)
+				if doc.RerankScore == nil || math.Abs(float64(*doc.RerankScore-tt.wantScores[i])) > 1e-6 {
+					t.Errorf("%s: RerankScore = %v, want %v", doc.Document.ID, doc.RerankScore, tt.wantScores[i])
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("Rerank() = %v, want %v", ids, tt.want)
+			}
+			if tt.docs[0].RerankScore != nil {
+				t.Error("Rerank() alterou os documentos de entrada")
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/openai"
+)
+
+// LLMReranker pontua os candidatos com um prompt listwise no modelo de chat
+type LLMReranker struct {
+	openaiClient *openai.Client
+}
+
+// NewLLMReranker cria um reranker baseado no modelo de chat
+func NewLLMReranker(openaiClient *openai.Client) *LLMReranker {
+	return &LLMReranker{openaiClient: openaiClient}
+}
+
+// Rerank reordena os documentos pela relevância atribuída pelo modelo
+func (r *LLMReranker) Rerank(ctx context.Context, query string, docs []models.RelevantDocument) ([]models.RelevantDocument, error) {
+	if len(docs) == 0 {
+		return docs, nil
+	}
+
+	passages := make([]string, len(docs))
+	for i, doc := range docs {
+		passages[i] = doc.Document.Content
+	}
+
+	scores, err := r.openaiClient.ScoreRelevance(ctx, query, passages)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao pontuar candidatos: %w", err)
+	}
+
+	reranked := make([]models.RelevantDocument, len(docs))
+	copy(reranked, docs)
+	for i := range reranked {
+		score := scores[i]
+		reranked[i].RerankScore = &score
+	}
+
+	sortByRerankScore(reranked)
+	return reranked, nil
+}
@@ -0,0 +1,26 @@
+package rerank
+
+import (
+	"context"
+
+	"github.com/marcopollivier/rag-go-ex01/internal/models"
+	"github.com/marcopollivier/rag-go-ex01/internal/ranking"
+)
+
+const (
+	// Lexical identifica o reranker local por sobreposição de termos
+	Lexical = "lexical"
+	// LLM identifica o reranker que usa o modelo de chat para pontuar os candidatos
+	LLM = "llm"
+)
+
+// Reranker reordena os candidatos recuperados pela busca vetorial
+type Reranker interface {
+	Rerank(ctx context.Context, query string, docs []models.RelevantDocument) ([]models.RelevantDocument, error)
+}
+
+// sortByRerankScore ordena os documentos pelo score de rerank com o score de ranking compartilhado,
+// de modo que o reforço por recência continua desempatando notas iguais do reranker
+func sortByRerankScore(docs []models.RelevantDocument) {
+	ranking.Sort(docs)
+}
@@ -0,0 +1,251 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Modos de isolamento dos dados de um tenant
+const (
+	// IsolationCollection guarda os documentos do tenant em coleções próprias
+	IsolationCollection = "collection"
+	// IsolationFilter guarda os documentos em coleções compartilhadas, marcados com o tenant e
+	// sempre filtrados por ele
+	IsolationFilter = "filter"
+)
+
+// ErrQuotaExceeded indica que o tenant atingiu a cota diária
+var ErrQuotaExceeded = errors.New("cota diária excedida")
+
+// validID restringe o ID do tenant a caracteres seguros em nomes de coleção
+var validID = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Quotas define os limites diários de um tenant; zero significa sem limite
+type Quotas struct {
+	DocumentsPerDay int `json:"documents_per_day"`
+	QueriesPerDay   int `json:"queries_per_day"`
+}
+
+// Tenant é um time ou cliente com dados isolados dos demais
+type Tenant struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	APIKeys []string `json:"api_keys"`
+	// Isolation é IsolationCollection (padrão) ou IsolationFilter
+	Isolation string `json:"isolation"`
+	Quotas    Quotas `json:"quotas"`
+}
+
+// Usage é o consumo do tenant no dia (UTC)
+type Usage struct {
+	Date      string `json:"date"`
+	Documents int    `json:"documents"`
+	Queries   int    `json:"queries"`
+}
+
+// Registry resolve tenants pela chave de API ou pelo ID e controla as cotas diárias. Um Registry
+// nil representa a implantação sem tenants: nada é resolvido e nenhuma cota é aplicada.
+type Registry struct {
+	tenants map[string]*Tenant
+	byKey   map[string]*Tenant
+
+	mu    sync.Mutex
+	usage map[string]*Usage
+	now   func() time.Time
+}
+
+// Load lê os tenants de um arquivo JSON no formato {"tenants": [...]}
+func Load(path string) (*Registry, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo de tenants: %w", err)
+	}
+
+	var file struct {
+		Tenants []Tenant `json:"tenants"`
+	}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar arquivo de tenants: %w", err)
+	}
+
+	return NewRegistry(file.Tenants)
+}
+
+// NewRegistry valida os tenants e cria o registro
+func NewRegistry(tenants []Tenant) (*Registry, error) {
+	if len(tenants) == 0 {
+		return nil, fmt.Errorf("nenhum tenant configurado")
+	}
+
+	r := &Registry{
+		tenants: make(map[string]*Tenant),
+		byKey:   make(map[string]*Tenant),
+		usage:   make(map[string]*Usage),
+		now:     time.Now,
+	}
+
+	for i := range tenants {
+		t := &tenants[i]
+		if !validID.MatchString(t.ID) {
+			return nil, fmt.Errorf("ID de tenant inválido: '%s' (use letras, números, '_' ou '-')", t.ID)
+		}
+		if _, ok := r.tenants[t.ID]; ok {
+			return nil, fmt.Errorf("tenant '%s' duplicado", t.ID)
+		}
+		switch t.Isolation {
+		case "":
+			t.Isolation = IsolationCollection
+		case IsolationCollection, IsolationFilter:
+		default:
+			return nil, fmt.Errorf("tenant '%s': isolamento inválido '%s'", t.ID, t.Isolation)
+		}
+		if t.Quotas.DocumentsPerDay < 0 || t.Quotas.QueriesPerDay < 0 {
+			return nil, fmt.Errorf("tenant '%s': cotas não podem ser negativas", t.ID)
+		}
+
+		for _, key := range t.APIKeys {
+			if key == "" {
+				return nil, fmt.Errorf("tenant '%s': chave de API vazia", t.ID)
+			}
+			if owner, ok := r.byKey[key]; ok {
+				return nil, fmt.Errorf("chave de API usada pelos tenants '%s' e '%s'", owner.ID, t.ID)
+			}
+			r.byKey[key] = t
+		}
+		r.tenants[t.ID] = t
+	}
+
+	return r, nil
+}
+
+// ByAPIKey retorna o tenant dono da chave de API
+func (r *Registry) ByAPIKey(key string) (*Tenant, bool) {
+	if r == nil || key == "" {
+		return nil, false
+	}
+	t, ok := r.byKey[key]
+	return t, ok
+}
+
+// ByID retorna o tenant pelo ID
+func (r *Registry) ByID(id string) (*Tenant, bool) {
+	if r == nil {
+		return nil, false
+	}
+	t, ok := r.tenants[id]
+	return t, ok
+}
+
+// All retorna os tenants configurados, ordenados pelo ID
+func (r *Registry) All() []*Tenant {
+	if r == nil {
+		return nil
+	}
+	tenants := make([]*Tenant, 0, len(r.tenants))
+	for _, t := range r.tenants {
+		tenants = append(tenants, t)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ID < tenants[j].ID })
+	return tenants
+}
+
+// ConsumeQuery registra uma consulta do tenant, falhando com ErrQuotaExceeded quando a cota do dia acabou
+func (r *Registry) ConsumeQuery(t *Tenant) error {
+	if r == nil || t == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage := r.today(t.ID)
+	if t.Quotas.QueriesPerDay > 0 && usage.Queries >= t.Quotas.QueriesPerDay {
+		return fmt.Errorf("%w: %d consultas por dia", ErrQuotaExceeded, t.Quotas.QueriesPerDay)
+	}
+	usage.Queries++
+	return nil
+}
+
+// ConsumeDocuments reserva n documentos da cota do dia; o lote inteiro é recusado quando não cabe
+func (r *Registry) ConsumeDocuments(t *Tenant, n int) error {
+	if r == nil || t == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage := r.today(t.ID)
+	if t.Quotas.DocumentsPerDay > 0 && usage.Documents+n > t.Quotas.DocumentsPerDay {
+		return fmt.Errorf("%w: %d documentos por dia (restam %d)", ErrQuotaExceeded,
+			t.Quotas.DocumentsPerDay, t.Quotas.DocumentsPerDay-usage.Documents)
+	}
+	usage.Documents += n
+	return nil
+}
+
+// ReleaseDocuments devolve à cota do dia documentos reservados que não foram indexados
+func (r *Registry) ReleaseDocuments(t *Tenant, n int) {
+	if r == nil || t == nil || n <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage := r.today(t.ID)
+	usage.Documents -= n
+	if usage.Documents < 0 {
+		usage.Documents = 0
+	}
+}
+
+// Usage retorna o consumo do tenant no dia
+func (r *Registry) Usage(t *Tenant) Usage {
+	if r == nil || t == nil {
+		return Usage{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.today(t.ID)
+}
+
+// today retorna o consumo do dia, reiniciando-o na virada do dia (UTC). Deve ser chamado com o lock adquirido.
+func (r *Registry) today(id string) *Usage {
+	date := r.now().UTC().Format("2006-01-02")
+	usage, ok := r.usage[id]
+	if !ok || usage.Date != date {
+		usage = &Usage{Date: date}
+		r.usage[id] = usage
+	}
+	return usage
+}
+
+type contextKey struct{}
+
+// WithContext associa o tenant ao contexto da requisição
+func WithContext(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext retorna o tenant da requisição (nil quando não há tenants)
+func FromContext(ctx context.Context) *Tenant {
+	t, _ := ctx.Value(contextKey{}).(*Tenant)
+	return t
+}
+
+// IDFromContext retorna o ID do tenant da requisição (vazio quando não há tenants)
+func IDFromContext(ctx context.Context) string {
+	if t := FromContext(ctx); t != nil {
+		return t.ID
+	}
+	return ""
+}
@@ -0,0 +1,198 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestRegistry(t *testing.T, quotas Quotas) (*Registry, *Tenant) {
+	t.Helper()
+	r, err := NewRegistry([]Tenant{{ID: "time-a", APIKeys: []string{"chave-a"}, Quotas: quotas}})
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	tenant, _ := r.ByID("time-a")
+	return r, tenant
+}
+
+func TestNewRegistryValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		tenants       []Tenant
+		wantErr       bool
+		wantIsolation string
+	}{
+		{name: "isolamento padrão por coleção", tenants: []Tenant{{ID: "a"}}, wantIsolation: IsolationCollection},
+		{name: "isolamento por filtro", tenants: []Tenant{{ID: "a", Isolation: IsolationFilter}}, wantIsolation: IsolationFilter},
+		{name: "sem tenants", tenants: nil, wantErr: true},
+		{name: "ID com ponto", tenants: []Tenant{{ID: "time.a"}}, wantErr: true},
+		{name: "ID vazio", tenants: []Tenant{{ID: ""}}, wantErr: true},
+		{name: "tenant duplicado", tenants: []Tenant{{ID: "a"}, {ID: "a"}}, wantErr: true},
+		{name: "isolamento inválido", tenants: []Tenant{{ID: "a", Isolation: "schema"}}, wantErr: true},
+		{name: "cota negativa", tenants: []Tenant{{ID: "a", Quotas: Quotas{QueriesPerDay: -1}}}, wantErr: true},
+		{name: "chave vazia", tenants: []Tenant{{ID: "a", APIKeys: []string{""}}}, wantErr: true},
+		{
+			name:    "chave compartilhada",
+			tenants: []Tenant{{ID: "a", APIKeys: []string{"k"}}, {ID: "b", APIKeys: []string{"k"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRegistry(tt.tenants)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRegistry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := r.All()[0].Isolation; got != tt.wantIsolation {
+				t.Errorf("isolamento = %q, want %q", got, tt.wantIsolation)
+			}
+		})
+	}
+}
+
+func TestConsumeQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		quota       int
+		calls       int
+		wantAllowed int
+	}{
+		{name: "sem limite", quota: 0, calls: 5, wantAllowed: 5},
+		{name: "dentro da cota", quota: 3, calls: 3, wantAllowed: 3},
+		{name: "acima da cota", quota: 2, calls: 5, wantAllowed: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, tenant := newTestRegistry(t, Quotas{QueriesPerDay: tt.quota})
+
+			allowed := 0
+			for i := 0; i < tt.calls; i++ {
+				err := r.ConsumeQuery(tenant)
+				switch {
+				case err == nil:
+					allowed++
+				case !errors.Is(err, ErrQuotaExceeded):
+					t.Fatalf("ConsumeQuery() error = %v, want ErrQuotaExceeded", err)
+				}
+			}
+
+			if allowed != tt.wantAllowed {
+				t.Errorf("consultas aceitas = %d, want %d", allowed, tt.wantAllowed)
+			}
+			if got := r.Usage(tenant).Queries; got != tt.wantAllowed {
+				t.Errorf("Usage().Queries = %d, want %d", got, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+func TestConsumeDocuments(t *testing.T) {
+	tests := []struct {
+		name     string
+		quota    int
+		consume  []int
+		release  int
+		wantErrs []bool
+		wantUsed int
+	}{
+		{name: "sem limite", quota: 0, consume: []int{100, 200}, wantErrs: []bool{false, false}, wantUsed: 300},
+		{name: "lote que cabe exatamente", quota: 10, consume: []int{4, 6}, wantErrs: []bool{false, false}, wantUsed: 10},
+		{name: "lote que não cabe é recusado inteiro", quota: 10, consume: []int{8, 3}, wantErrs: []bool{false, true}, wantUsed: 8},
+		{name: "devolução libera a cota", quota: 10, consume: []int{10}, release: 4, wantErrs: []bool{false}, wantUsed: 6},
+		{name: "devolução não deixa o consumo negativo", quota: 10, consume: []int{2}, release: 5, wantErrs: []bool{false}, wantUsed: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, tenant := newTestRegistry(t, Quotas{DocumentsPerDay: tt.quota})
+
+			for i, n := range tt.consume {
+				err := r.ConsumeDocuments(tenant, n)
+				if (err != nil) != tt.wantErrs[i] {
+					t.Errorf("ConsumeDocuments(%d) error = %v, wantErr %v", n, err, tt.wantErrs[i])
+				}
+				if err != nil && !errors.Is(err, ErrQuotaExceeded) {
+					t.Errorf("ConsumeDocuments(%d) error = %v, want ErrQuotaExceeded", n, err)
+				}
+			}
+			r.ReleaseDocuments(tenant, tt.release)
+
+			if got := r.Usage(tenant).Documents; got != tt.wantUsed {
+				t.Errorf("Usage().Documents = %d, want %d", got, tt.wantUsed)
+			}
+		})
+	}
+}
+
+func TestUsageResetsDaily(t *testing.T) {
+	r, tenant := newTestRegistry(t, Quotas{QueriesPerDay: 1})
+	now := time.Date(2025, 8, 9, 23, 59, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	if err := r.ConsumeQuery(tenant); err != nil {
+		t.Fatalf("ConsumeQuery() error = %v", err)
+	}
+	if err := r.ConsumeQuery(tenant); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("ConsumeQuery() error = %v, want ErrQuotaExceeded", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if err := r.ConsumeQuery(tenant); err != nil {
+		t.Errorf("ConsumeQuery() no dia seguinte error = %v", err)
+	}
+	if got := r.Usage(tenant); got.Date != "2025-08-10" || got.Queries != 1 {
+		t.Errorf("Usage() = %+v, want 1 consulta em 2025-08-10", got)
+	}
+}
+
+func TestNilRegistry(t *testing.T) {
+	var r *Registry
+	tenant := &Tenant{ID: "a", Quotas: Quotas{QueriesPerDay: 1, DocumentsPerDay: 1}}
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{name: "ConsumeQuery", call: func() error { return r.ConsumeQuery(tenant) }},
+		{name: "ConsumeDocuments", call: func() error { return r.ConsumeDocuments(tenant, 10) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); err != nil {
+				t.Errorf("%s() em registro nil error = %v", tt.name, err)
+			}
+		})
+	}
+
+	if _, ok := r.ByAPIKey("chave"); ok {
+		t.Error("ByAPIKey() em registro nil encontrou tenant")
+	}
+}
+
+func TestContext(t *testing.T) {
+	tenant := &Tenant{ID: "time-a"}
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		wantID string
+	}{
+		{name: "com tenant", ctx: WithContext(context.Background(), tenant), wantID: "time-a"},
+		{name: "sem tenant", ctx: context.Background(), wantID: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IDFromContext(tt.ctx); got != tt.wantID {
+				t.Errorf("IDFromContext() = %q, want %q", got, tt.wantID)
+			}
+		})
+	}
+}